@@ -0,0 +1,144 @@
+package gofigure
+
+import (
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// A file referring to the anchors of other files is parsed again nested under documentKey, after placeholders of the
+// anchors defined under anchorsKey, which its aliases refer to instead.
+const (
+	anchorsKey  = "__gofigure_anchors__"
+	documentKey = "__gofigure_document__"
+	// anchorTag tags the placeholders of the anchors
+	anchorTag = "!__gofigure_anchor__"
+	// documentIndent is how much the lines of the file are indented under documentKey
+	documentIndent = 2
+)
+
+// NodeAnchors makes the anchors available to the aliases of a YAML file, in addition to the ones it defines itself,
+// which take precedence. The aliases are set to copies of the anchors, which keep the file and the positions they
+// were defined at. A Loader passes the anchors of the files loaded before to its decoders.
+func NodeAnchors(anchors map[string]*Node) NodeOption {
+	return nodeOptionFunc(func(o *nodeOptions) {
+		o.anchors = anchors
+	})
+}
+
+// collectAnchors returns anchors with the anchors defined under node added, or anchors itself if there are none.
+func collectAnchors(anchors map[string]*Node, node *Node) map[string]*Node {
+	var found []*Node
+	var walk func(node *Node)
+	walk = func(node *Node) {
+		if node == nil {
+			return
+		}
+		if node.anchor != "" {
+			found = append(found, node)
+		}
+		for _, key := range node.MappingKeys() {
+			walk(node.mappingNodes[key])
+		}
+		for _, child := range node.sequenceNodes {
+			walk(child)
+		}
+	}
+	walk(node)
+	if len(found) == 0 {
+		return anchors
+	}
+
+	registry := make(map[string]*Node, len(anchors)+len(found))
+	for name, anchor := range anchors {
+		registry[name] = anchor
+	}
+	for _, node := range found {
+		// a copy, as nodes are changed in place when merged, detached from the file but still set by it
+		anchor := node.clone()
+		anchor.parent = nil
+		anchor.filepath, anchor.source = node.Filepath(), node.Source()
+		registry[node.anchor] = anchor
+	}
+	return registry
+}
+
+// setAnchorValue sets n to a copy of anchor, in place of an alias to its placeholder.
+func (n *Node) setAnchorValue(anchor *Node) {
+	c := anchor.clone()
+	c.parent = n.parent
+	c.mappingKey, c.hasMappingKey = n.mappingKey, n.hasMappingKey
+	c.sequenceIndex, c.hasSequenceIndex = n.sequenceIndex, n.hasSequenceIndex
+	c.anchor = ""
+	*n = *c
+	for _, child := range n.mappingNodes {
+		n.adopt(c, child)
+	}
+	for _, child := range n.sequenceNodes {
+		n.adopt(c, child)
+	}
+}
+
+// unmarshalWithAnchors unmarshals contents, which failed to unmarshal on its own, nested under documentKey after the
+// placeholders of anchors. The nesting is removed once parsed, so the positions of the nodes stay the same. It returns
+// false if contents still fails to unmarshal, or can not be nested as it has several documents or directives.
+func unmarshalWithAnchors(contents []byte, anchors map[string]*Node, document *yaml.Node) bool {
+	names := make([]string, 0, len(anchors))
+	for name := range anchors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var nested strings.Builder
+	nested.WriteString(anchorsKey + ": [")
+	for i, name := range names {
+		if i > 0 {
+			nested.WriteString(", ")
+		}
+		nested.WriteString("&" + name + " " + anchorTag + " ''")
+	}
+	nested.WriteString("]\n" + documentKey + ":\n")
+	indent := strings.Repeat(" ", documentIndent)
+	for _, line := range strings.SplitAfter(string(contents), "\n") {
+		if strings.HasPrefix(line, "---") || strings.HasPrefix(line, "...") || strings.HasPrefix(line, "%") {
+			return false
+		}
+		if strings.TrimSpace(line) != "" {
+			nested.WriteString(indent)
+		}
+		nested.WriteString(line)
+	}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal([]byte(nested.String()), &root); err != nil {
+		return false
+	}
+	mapping := root.Content[0]
+	if len(mapping.Content) != 4 || mapping.Content[2].Value != documentKey {
+		return false
+	}
+	value := mapping.Content[3]
+	walkYAML(value, func(node *yaml.Node) {
+		node.Line -= 2
+		node.Column -= documentIndent
+	})
+	*document = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{value}}
+	// the leading comments up to their last blank line are the ones of the document when it is not nested
+	if len(value.Content) > 0 {
+		first := value.Content[0]
+		if i := strings.LastIndex(first.HeadComment, "\n\n"); i >= 0 {
+			document.HeadComment = first.HeadComment[:i]
+			first.HeadComment = strings.TrimLeft(first.HeadComment[i:], "\n")
+		}
+	}
+	return true
+}
+
+// walkYAML calls fn with node and every node under it, aliases are not followed.
+func walkYAML(node *yaml.Node, fn func(node *yaml.Node)) {
+	fn(node)
+	for _, child := range node.Content {
+		walkYAML(child, fn)
+	}
+}
@@ -0,0 +1,181 @@
+package gofigure
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/psanford/memfs"
+)
+
+var _ = Describe("anchors", func() {
+	get := func(loader *Loader, path string) any {
+		var value any
+		Expect(loader.Get(context.Background(), path, &value)).To(BeNil())
+		return value
+	}
+
+	It("should merge the keys of merge keys", func() {
+		loader := New()
+		Expect(loader.Load("app.yaml", []byte(`base: &base
+  host: localhost
+  port: 5432
+  options: {ssl: true}
+primary:
+  host: primary
+  <<: *base
+replica:
+  <<: *base
+  port: 6432
+inline:
+  <<: {host: inline, port: 1}
+  port: 2
+`))).To(BeNil())
+		Expect(get(loader, "app.primary")).To(Equal(map[string]any{
+			"host": "primary", "port": 5432, "options": map[string]any{"ssl": true},
+		}))
+		Expect(get(loader, "app.replica")).To(Equal(map[string]any{
+			"host": "localhost", "port": 6432, "options": map[string]any{"ssl": true},
+		}))
+		Expect(get(loader, "app.inline")).To(Equal(map[string]any{"host": "inline", "port": 2}))
+
+		node, err := loader.GetNode(context.Background(), "app.replica")
+		Expect(err).To(BeNil())
+		Expect(node.MappingKeys()).To(Equal([]string{"host", "port", "options"}))
+		Expect(node.mappingNodes["host"].Keypath()).To(Equal("app.replica.host"))
+		Expect(node.mappingNodes["host"].line).To(Equal(2))
+	})
+
+	It("should merge the mappings of a sequence, the first ones first", func() {
+		loader := New()
+		Expect(loader.Load("app.yaml", []byte(`a: &a {x: a, y: a}
+b: &b {x: b, y: b, z: b}
+c:
+  <<: [*a, *b]
+  y: c
+`))).To(BeNil())
+		Expect(get(loader, "app.c")).To(Equal(map[string]any{"x": "a", "y": "c", "z": "b"}))
+	})
+
+	It("should keep << keys that do not merge mappings", func() {
+		loader := New()
+		Expect(loader.Load("app.yaml", []byte(`a:
+  <<: value
+  '<<': quoted
+`))).To(BeNil())
+		node, err := loader.GetNode(context.Background(), "app.a")
+		Expect(err).To(BeNil())
+		Expect(node.MappingKeys()).To(Equal([]string{"<<"}))
+		Expect(node.mappingNodes["<<"].Value()).To(Equal("quoted"))
+	})
+
+	It("should use the anchors of the files loaded before", func() {
+		loader := New()
+		Expect(loader.Load("defaults.yaml", []byte(`db: &db_defaults
+  host: localhost
+  port: 5432
+  description: |
+    the
+    database
+`))).To(BeNil())
+		Expect(loader.Load("app.yaml", []byte(`# the app
+
+# the database
+db:
+  <<: *db_defaults
+  port: 6432
+copy: *db_defaults
+`))).To(BeNil())
+		Expect(get(loader, "app.db")).To(Equal(map[string]any{
+			"host": "localhost", "port": 6432, "description": "the\ndatabase\n",
+		}))
+		Expect(get(loader, "app.copy.host")).To(Equal("localhost"))
+
+		node, err := loader.GetNode(context.Background(), "app.db.port")
+		Expect(err).To(BeNil())
+		Expect(node.line).To(Equal(6))
+		Expect(node.column).To(Equal(9))
+		node, err = loader.GetNode(context.Background(), "app.db")
+		Expect(err).To(BeNil())
+		Expect(node.KeyHeadComment()).To(Equal("# the database"))
+	})
+
+	It("should prefer the anchors of the file itself", func() {
+		loader := New()
+		Expect(loader.Load("defaults.yaml", []byte(`x: &value defaults`))).To(BeNil())
+		Expect(loader.Load("app.yaml", []byte(`x: &value app
+y: *value`))).To(BeNil())
+		Expect(get(loader, "app.y")).To(Equal("app"))
+	})
+
+	It("should use the anchors after reloading", func() {
+		loader := New()
+		Expect(loader.Load("defaults.yaml", []byte(`x: &value defaults`))).To(BeNil())
+		Expect(loader.Load("app.yaml", []byte(`y: *value`))).To(BeNil())
+		_, err := loader.Reload(context.Background())
+		Expect(err).To(BeNil())
+		Expect(get(loader, "app.y")).To(Equal("defaults"))
+	})
+
+	It("should use the anchors of the files reloaded for the files loaded after", func() {
+		fs := memfs.New()
+		Expect(fs.WriteFile("base.yaml", []byte(`db: &db {host: old}`), 0644)).To(BeNil())
+		loader := New()
+		Expect(loader.LoadFS(fs)).To(BeNil())
+		Expect(fs.WriteFile("base.yaml", []byte(`db: &db {host: new}`), 0644)).To(BeNil())
+		_, err := loader.Reload(context.Background())
+		Expect(err).To(BeNil())
+
+		Expect(loader.Load("over.yaml", []byte(`copy: *db`))).To(BeNil())
+		Expect(get(loader, "over.copy.host")).To(Equal("new"))
+	})
+
+	It("should not use unknown anchors", func() {
+		loader := New()
+		Expect(loader.Load("app.yaml", []byte(`y: *value`))).To(MatchError(ContainSubstring("unknown anchor 'value' referenced")))
+		Expect(loader.Load("defaults.yaml", []byte(`x: &value defaults`))).To(BeNil())
+		Expect(loader.Load("app.yaml", []byte(`---
+y: *other`))).To(MatchError(ContainSubstring("unknown anchor 'other' referenced")))
+	})
+
+	It("should explain the values of the anchors of other files", func() {
+		loader := New()
+		Expect(loader.Load("base.yaml", []byte(`db: &db
+  host: localhost
+  port: 5432
+`))).To(BeNil())
+		Expect(loader.Load("over.yaml", []byte(`db:
+  <<: *db
+  port: 6432
+copy: *db
+`))).To(BeNil())
+
+		explanation, err := loader.Explain(context.Background(), "over.db.host")
+		Expect(err).To(BeNil())
+		Expect(explanation.Origins).To(Equal([]Origin{{Source: "base.yaml", Line: 2, Column: 9, Tag: "!!str", Value: "localhost"}}))
+		explanation, err = loader.Explain(context.Background(), "over.db.port")
+		Expect(err).To(BeNil())
+		Expect(explanation.Origins).To(Equal([]Origin{{Source: "over.yaml", Line: 3, Column: 9, Tag: "!!int", Value: "6432"}}))
+		explanation, err = loader.Explain(context.Background(), "over.copy.port")
+		Expect(err).To(BeNil())
+		Expect(explanation.Origins).To(Equal([]Origin{{Source: "base.yaml", Line: 3, Column: 9, Tag: "!!int", Value: "5432"}}))
+	})
+
+	It("should decode with the given anchors", func() {
+		anchors := map[string]*Node{"a": NewScalarNode("value", NodeFilepath("defaults.yaml"))}
+		node, err := YAMLDecoder().Decode([]byte(`x: *a`), NodeAnchors(anchors))
+		Expect(err).To(BeNil())
+		Expect(node.mappingNodes["x"].Value()).To(Equal("value"))
+		Expect(node.mappingNodes["x"].Source()).To(Equal("defaults.yaml"))
+
+		node, err = YAMLDecoder().Decode([]byte(`- [*a]
+- *a`), NodeAnchors(anchors))
+		Expect(err).To(BeNil())
+		Expect(node.sequenceNodes[0].sequenceNodes[0].Value()).To(Equal("value"))
+		Expect(node.sequenceNodes[1].Value()).To(Equal("value"))
+		// the aliases have the positions of the anchors
+		Expect(node.sequenceNodes[0].line).To(Equal(1))
+		Expect(node.sequenceNodes[0].column).To(Equal(3))
+		Expect(node.sequenceNodes[1].line).To(BeZero())
+	})
+})
@@ -0,0 +1,91 @@
+package gofigure
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ByteSize is a number of bytes, written as a plain number or with a unit: B, KB, MB, GB, TB and PB are powers of
+// 1000, KiB, MiB, GiB, TiB and PiB, as well as K, M, G, T and P, powers of 1024. Units are case-insensitive.
+type ByteSize int64
+
+var byteSizeUnits = map[string]float64{
+	"":    1,
+	"b":   1,
+	"kb":  1e3,
+	"mb":  1e6,
+	"gb":  1e9,
+	"tb":  1e12,
+	"pb":  1e15,
+	"k":   1 << 10,
+	"kib": 1 << 10,
+	"m":   1 << 20,
+	"mib": 1 << 20,
+	"g":   1 << 30,
+	"gib": 1 << 30,
+	"t":   1 << 40,
+	"tib": 1 << 40,
+	"p":   1 << 50,
+	"pib": 1 << 50,
+}
+
+// ParseByteSize parses a byte size, e.g. 512, 64KB or 1.5GiB.
+func ParseByteSize(s string) (ByteSize, error) {
+	trimmed := strings.TrimSpace(s)
+	i := strings.IndexFunc(trimmed, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.' && r != '_'
+	})
+	if i < 0 {
+		i = len(trimmed)
+	}
+	number, unit := trimmed[:i], strings.ToLower(strings.TrimSpace(trimmed[i:]))
+
+	value, err := strconv.ParseFloat(strings.ReplaceAll(number, "_", ""), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid byte size %q", s)
+	}
+	multiplier, ok := byteSizeUnits[unit]
+	if !ok {
+		return 0, fmt.Errorf("invalid byte size %q: unknown unit %q", s, trimmed[i:])
+	}
+	bytes := math.Round(value * multiplier)
+	if bytes >= math.MaxInt64 {
+		return 0, fmt.Errorf("invalid byte size %q: out of range", s)
+	}
+	return ByteSize(bytes), nil
+}
+
+func (b *ByteSize) UnmarshalText(text []byte) error {
+	size, err := ParseByteSize(string(text))
+	if err != nil {
+		return err
+	}
+	*b = size
+	return nil
+}
+
+func (b *ByteSize) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind != yaml.ScalarNode {
+		return fmt.Errorf("cannot decode a non scalar value into a byte size")
+	}
+	return b.UnmarshalText([]byte(value.Value))
+}
+
+// String formats the size with the largest power of 1024 unit it is a whole multiple of, e.g. 64KiB.
+func (b ByteSize) String() string {
+	for _, unit := range []string{"PiB", "TiB", "GiB", "MiB", "KiB"} {
+		multiplier := ByteSize(byteSizeUnits[strings.ToLower(unit)])
+		if b != 0 && b%multiplier == 0 {
+			return strconv.FormatInt(int64(b/multiplier), 10) + unit
+		}
+	}
+	return strconv.FormatInt(int64(b), 10) + "B"
+}
+
+func (b ByteSize) MarshalText() ([]byte, error) {
+	return []byte(b.String()), nil
+}
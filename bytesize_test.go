@@ -0,0 +1,43 @@
+package gofigure
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ByteSize", func() {
+	DescribeTable("should parse",
+		func(s string, expected ByteSize) {
+			size, err := ParseByteSize(s)
+			Expect(err).To(BeNil())
+			Expect(size).To(Equal(expected))
+		},
+		Entry("bytes", "512", ByteSize(512)),
+		Entry("bytes with unit", "512B", ByteSize(512)),
+		Entry("SI", "64KB", ByteSize(64000)),
+		Entry("IEC", "64KiB", ByteSize(65536)),
+		Entry("short", "64k", ByteSize(65536)),
+		Entry("fraction", "1.5GiB", ByteSize(1536<<20)),
+		Entry("space", "10 MB", ByteSize(10_000_000)),
+		Entry("underscores", "1_000", ByteSize(1000)),
+	)
+
+	DescribeTable("should not parse",
+		func(s, message string) {
+			_, err := ParseByteSize(s)
+			Expect(err).NotTo(BeNil())
+			Expect(err.Error()).To(Equal(message))
+		},
+		Entry("empty", "", `invalid byte size ""`),
+		Entry("negative", "-1KB", `invalid byte size "-1KB"`),
+		Entry("unit", "12XB", `invalid byte size "12XB": unknown unit "XB"`),
+		Entry("range", "9000PiB", `invalid byte size "9000PiB": out of range`),
+	)
+
+	It("should format", func() {
+		Expect(ByteSize(0).String()).To(Equal("0B"))
+		Expect(ByteSize(1000).String()).To(Equal("1000B"))
+		Expect(ByteSize(65536).String()).To(Equal("64KiB"))
+		Expect(ByteSize(3 << 30).String()).To(Equal("3GiB"))
+	})
+})
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"filippo.io/age"
+	"gopkg.in/yaml.v3"
+
+	"github.com/joesonw/gofigure"
+	"github.com/joesonw/gofigure/feature"
+	"github.com/joesonw/gofigure/feature/agecrypt"
+)
+
+// decryptor returns the !decrypt feature with the keys of -keyring and -identity, or nil if neither is set.
+func (o *options) decryptor() (*feature.DecryptFeature, error) {
+	if o.keyring == "" && o.identity == "" {
+		return nil, nil
+	}
+	decrypt := feature.Decrypt()
+	if o.keyring != "" {
+		decrypt.Keyring(feature.FileKeyring(os.DirFS(o.keyring)))
+	}
+	if o.identity != "" {
+		f, err := os.Open(o.identity)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		identities, err := age.ParseIdentities(f)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", o.identity, err)
+		}
+		decrypt.Age(agecrypt.Identities(identities...))
+	}
+	return decrypt, nil
+}
+
+// encryptValue encrypts plaintext for the recipients of -recipient, or else with the key -key-id of -keyring.
+func (o *options) encryptValue(ctx context.Context, plaintext []byte) (string, error) {
+	switch {
+	case o.recipients != "" && o.keyID != "":
+		return "", errors.New("-recipient and -key-id cannot be used together")
+	case o.recipients != "":
+		var recipients []age.Recipient
+		for _, s := range strings.Split(o.recipients, ",") {
+			if s = strings.TrimSpace(s); s == "" {
+				continue
+			}
+			recipient, err := age.ParseX25519Recipient(s)
+			if err != nil {
+				return "", err
+			}
+			recipients = append(recipients, recipient)
+		}
+		return agecrypt.Encrypt(plaintext, recipients...)
+	case o.keyID != "":
+		if o.keyring == "" {
+			return "", errors.New("-key-id requires -keyring")
+		}
+		key, err := feature.FileKeyring(os.DirFS(o.keyring)).Key(ctx, o.keyID)
+		if err != nil {
+			return "", fmt.Errorf("unable to get key %q: %w", o.keyID, err)
+		}
+		return feature.EncryptAES(key, o.keyID, plaintext)
+	}
+	return "", errors.New("either -recipient or -key-id is required")
+}
+
+// readValue reads a value from r, without the line break ending it, e.g. from echo.
+func readValue(r io.Reader) (*string, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the value: %w", err)
+	}
+	value := strings.TrimSuffix(strings.TrimSuffix(string(b), "\n"), "\r")
+	return &value, nil
+}
+
+// encrypt replaces the value at path in file, relative to the config directory, with its !decrypt ciphertext. The
+// value is either the given one, read from stdin, or, if there is none, the current one, decrypted if it is already
+// encrypted, so that keys can be rotated. Only the text of the value is rewritten, the rest of the file is kept as it is.
+func encrypt(ctx context.Context, o *options, file, path string, value *string) error {
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(o.dir, file)
+	}
+	info, err := os.Stat(file)
+	if err != nil {
+		return err
+	}
+	contents, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	var document yaml.Node
+	if err := yaml.Unmarshal(contents, &document); err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+	node, indent, err := findYAMLValue(&document, path)
+	if err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+
+	var plaintext []byte
+	switch {
+	case value != nil:
+		plaintext = []byte(*value)
+	case node.Tag == "!decrypt":
+		decrypt, err := o.decryptor()
+		if err != nil {
+			return err
+		}
+		if decrypt == nil {
+			return fmt.Errorf("%s: %s is encrypted, -keyring or -identity is required to rotate it", file, path)
+		}
+		if plaintext, err = decrypt.DecryptValue(ctx, node.Value); err != nil {
+			return fmt.Errorf("%s: %s: %w", file, path, err)
+		}
+	default:
+		plaintext = []byte(node.Value)
+	}
+
+	ciphertext, err := o.encryptValue(ctx, plaintext)
+	if err != nil {
+		return err
+	}
+	contents, err = replaceYAMLValue(contents, node, indent, ciphertext)
+	if err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+	return os.WriteFile(file, contents, info.Mode().Perm())
+}
+
+// findYAMLValue returns the scalar at path in document, and the indentation of its key or sequence item, which the
+// lines of a value spanning multiple lines are indented deeper than.
+func findYAMLValue(document *yaml.Node, path string) (*yaml.Node, int, error) {
+	if document.Kind != yaml.DocumentNode || len(document.Content) == 0 {
+		return nil, 0, fmt.Errorf("%s: %w", path, gofigure.ErrPathNotFound)
+	}
+	paths, err := gofigure.ParseDotPath(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(paths) == 0 {
+		return nil, 0, errors.New("expected the path of a value")
+	}
+
+	node, indent := document.Content[0], 0
+	for _, p := range paths {
+		if node.Style&yaml.FlowStyle != 0 {
+			return nil, 0, fmt.Errorf("%s: values in flow collections cannot be encrypted", path)
+		}
+		var next *yaml.Node
+		switch {
+		case node.Kind == yaml.MappingNode && p.Key != "":
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if node.Content[i].Value == p.Key {
+					next, indent = node.Content[i+1], node.Content[i].Column-1
+				}
+			}
+		case node.Kind == yaml.SequenceNode && p.Key == "":
+			if p.Index < len(node.Content) {
+				next = node.Content[p.Index]
+				// the item is indented deeper than its dash, which is the first thing on its line
+				indent = next.Column - 3
+			}
+		}
+		if next == nil {
+			return nil, 0, fmt.Errorf("%s: %w", path, gofigure.ErrPathNotFound)
+		}
+		node = next
+	}
+	if node.Kind != yaml.ScalarNode {
+		return nil, 0, fmt.Errorf("%s: only scalar values can be encrypted", path)
+	}
+	return node, indent, nil
+}
+
+// replaceYAMLValue replaces the text of node, and of its tag, in contents with a !decrypt of ciphertext. The value
+// spans its first line and the following ones which are blank or indented deeper than indent.
+func replaceYAMLValue(contents []byte, node *yaml.Node, indent int, ciphertext string) ([]byte, error) {
+	lines := strings.SplitAfter(string(contents), "\n")
+	first := node.Line - 1
+	if first < 0 || first >= len(lines) {
+		return nil, fmt.Errorf("%d:%d: value not found", node.Line, node.Column)
+	}
+	last := first
+	for i := first + 1; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], "\r\n")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if len(line)-len(strings.TrimLeft(line, " ")) <= indent {
+			break
+		}
+		last = i
+	}
+
+	prefix := []rune(lines[first])
+	if node.Column-1 > len(prefix) {
+		return nil, fmt.Errorf("%d:%d: value not found", node.Line, node.Column)
+	}
+	comment := ""
+	if node.LineComment != "" {
+		comment = " " + node.LineComment
+	}
+
+	var b strings.Builder
+	b.WriteString(string(prefix[:node.Column-1]))
+	if ciphertext = strings.TrimSpace(ciphertext); strings.Contains(ciphertext, "\n") {
+		b.WriteString("!decrypt |" + comment + "\n")
+		for _, line := range strings.Split(ciphertext, "\n") {
+			b.WriteString(strings.Repeat(" ", indent+2) + line + "\n")
+		}
+	} else {
+		b.WriteString("!decrypt " + ciphertext + comment + "\n")
+	}
+	replacement := b.String()
+	if !strings.HasSuffix(lines[last], "\n") {
+		replacement = strings.TrimSuffix(replacement, "\n")
+	}
+
+	result := strings.Join(lines[:first], "") + replacement + strings.Join(lines[last+1:], "")
+	return []byte(result), nil
+}
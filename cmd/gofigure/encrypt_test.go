@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"filippo.io/age"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("encrypt", func() {
+	const contents = `# database
+db:
+  host: localhost # the host
+  password: hunter2 # the password
+  replicas:
+  - name: a
+    password: |
+      first
+      line
+  - b
+port: 8080
+`
+	var dir, keyring string
+
+	BeforeEach(func() {
+		dir, keyring = GinkgoT().TempDir(), GinkgoT().TempDir()
+		Expect(os.WriteFile(filepath.Join(dir, "app.yaml"), []byte(contents), 0600)).To(BeNil())
+		key := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32))
+		Expect(os.WriteFile(filepath.Join(keyring, "v1"), []byte(key), 0600)).To(BeNil())
+		Expect(os.WriteFile(filepath.Join(keyring, "v2"), []byte(strings.ToLower(key)), 0600)).To(BeNil())
+	})
+
+	runWithStdin := func(stdin string, args ...string) (int, string, string) {
+		var stdout, stderr bytes.Buffer
+		code := run(append([]string{"-dir", dir, "-keyring", keyring}, args...), strings.NewReader(stdin), &stdout, &stderr)
+		return code, stdout.String(), stderr.String()
+	}
+	run := func(args ...string) (int, string, string) {
+		return runWithStdin("", args...)
+	}
+
+	read := func() string {
+		b, err := os.ReadFile(filepath.Join(dir, "app.yaml"))
+		Expect(err).To(BeNil())
+		return string(b)
+	}
+
+	It("should encrypt a value in place with an AES key", func() {
+		code, _, stderr := run("-key-id", "v1", "encrypt", "app.yaml", "db.password")
+		Expect(stderr).To(Equal(""))
+		Expect(code).To(Equal(0))
+		lines := strings.Split(read(), "\n")
+		Expect(lines[3]).To(MatchRegexp(`^  password: !decrypt aes-gcm:v1:\S+ # the password$`))
+		lines[3] = "  password: hunter2 # the password"
+		Expect(strings.Join(lines, "\n")).To(Equal(contents))
+
+		code, stdout, _ := run("get", "app.db.password", "-reveal")
+		Expect(code).To(Equal(0))
+		Expect(stdout).To(Equal("hunter2\n"))
+
+		code, stdout, _ = run("get", "app.db.password")
+		Expect(code).To(Equal(0))
+		Expect(stdout).To(Equal("******\n"))
+	})
+
+	It("should rotate an encrypted value to another key", func() {
+		Expect(run("-key-id", "v1", "encrypt", "app.yaml", "db.password")).To(Equal(0))
+		Expect(run("-key-id", "v2", "encrypt", "app.yaml", "db.password")).To(Equal(0))
+		Expect(read()).To(ContainSubstring("password: !decrypt aes-gcm:v2:"))
+
+		code, stdout, _ := run("get", "app.db.password", "-reveal")
+		Expect(code).To(Equal(0))
+		Expect(stdout).To(Equal("hunter2\n"))
+	})
+
+	It("should encrypt a given value of a sequence item for age recipients", func() {
+		identity, err := age.GenerateX25519Identity()
+		Expect(err).To(BeNil())
+		identityFile := filepath.Join(keyring, "identity.txt")
+		Expect(os.WriteFile(identityFile, []byte(identity.String()), 0600)).To(BeNil())
+
+		code, _, stderr := runWithStdin("s3cret\n", "-recipient", identity.Recipient().String(), "encrypt", "app.yaml", "db.replicas[0].password", "-")
+		Expect(stderr).To(Equal(""))
+		Expect(code).To(Equal(0))
+		result := read()
+		Expect(result).To(HavePrefix(`# database
+db:
+  host: localhost # the host
+  password: hunter2 # the password
+  replicas:
+  - name: a
+    password: !decrypt |
+      -----BEGIN AGE ENCRYPTED FILE-----
+`))
+		Expect(result).To(HaveSuffix(`
+      -----END AGE ENCRYPTED FILE-----
+  - b
+port: 8080
+`))
+
+		code, stdout, _ := run("-identity", identityFile, "get", "app.db.replicas[0].password")
+		Expect(code).To(Equal(0))
+		Expect(stdout).To(Equal("******\n"))
+		code, stdout, _ = run("-identity", identityFile, "-reveal", "get", "app.db.replicas[0].password")
+		Expect(code).To(Equal(0))
+		Expect(stdout).To(Equal("s3cret\n"))
+	})
+
+	It("should not encrypt what it cannot", func() {
+		code, _, stderr := run("encrypt", "app.yaml", "db.password")
+		Expect(code).To(Equal(1))
+		Expect(stderr).To(Equal("gofigure: either -recipient or -key-id is required\n"))
+
+		code, _, stderr = run("-key-id", "v1", "encrypt", "app.yaml", "db.replicas")
+		Expect(code).To(Equal(1))
+		Expect(stderr).To(Equal("gofigure: " + filepath.Join(dir, "app.yaml") + ": db.replicas: only scalar values can be encrypted\n"))
+
+		code, _, stderr = run("-key-id", "v1", "encrypt", "app.yaml", "db.missing")
+		Expect(code).To(Equal(1))
+		Expect(stderr).To(Equal("gofigure: " + filepath.Join(dir, "app.yaml") + ": db.missing: path not found\n"))
+
+		code, _, stderr = run("-key-id", "v1", "encrypt", "app.yaml", "db.password", "hunter3")
+		Expect(code).To(Equal(2))
+		Expect(stderr).To(Equal("gofigure: encrypt reads the value from stdin, pass - instead of the value\n"))
+
+		code, _, stderr = run("-key-id", "v3", "encrypt", "app.yaml", "db.password")
+		Expect(code).To(Equal(1))
+		Expect(stderr).To(HavePrefix(`gofigure: unable to get key "v3": `))
+		Expect(read()).To(Equal(contents))
+	})
+})
@@ -0,0 +1,379 @@
+// Command gofigure inspects a config directory the way a program using gofigure loads it.
+//
+//	gofigure [flags] get <path>                  print the resolved value at path
+//	gofigure [flags] dump                        print the whole resolved config
+//	gofigure [flags] explain <path>              show where the value at path was set
+//	gofigure [flags] validate                    check that every value resolves, and matches -schema if set
+//	gofigure [flags] diff <profiles> <profiles>  compare two comma separated lists of profiles
+//	gofigure [flags] encrypt <file> <path> [-]   encrypt the value at path in file for !decrypt
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/joesonw/gofigure"
+	"github.com/joesonw/gofigure/feature"
+	"github.com/joesonw/gofigure/schema"
+)
+
+const usage = `usage: gofigure [flags] <command> [arguments]
+
+commands:
+  get <path>                  print the resolved value at path
+  dump                        print the whole resolved config
+  explain <path>              show where the value at path was set
+  validate                    check that every value resolves, and matches -schema if set
+  diff <profiles> <profiles>  compare two comma separated lists of profiles
+  encrypt <file> <path> [-]   encrypt the value at path in file for !decrypt, the one read from stdin
+                              with -, or else the current one, which is re-encrypted if encrypted
+
+flags:
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+type options struct {
+	dir           string
+	profiles      string
+	knownProfiles string
+	envPrefix     string
+	format        string
+	provenance    bool
+	mask          string
+	reveal        bool
+	schema        string
+	keyring       string
+	keyID         string
+	identity      string
+	recipients    string
+}
+
+// run runs the command line args, reading stdin and writing to stdout and stderr, and returns the exit code: 1 if the
+// command failed, or for diff if the profiles differ, and 2 for invalid usage.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	o := &options{}
+	fs := flag.NewFlagSet("gofigure", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&o.dir, "dir", ".", "config directory")
+	fs.StringVar(&o.profiles, "profiles", "", "comma separated active profiles, e.g. prod,eu-west")
+	fs.StringVar(&o.knownProfiles, "known-profiles", "", "comma separated inactive profiles, whose directories are skipped")
+	fs.StringVar(&o.envPrefix, "env-prefix", "", "overlay the environment variables with this prefix, e.g. APP for APP__PORT")
+	fs.StringVar(&o.format, "format", "yaml", "output format: yaml, json, toml, dotenv or properties")
+	fs.BoolVar(&o.provenance, "provenance", false, "comment every value with where it was set")
+	fs.StringVar(&o.mask, "mask", "", "comma separated path patterns of the values to mask, e.g. **.password")
+	fs.BoolVar(&o.reveal, "reveal", false, "show sensitive values, such as secrets, instead of redacting them")
+	fs.StringVar(&o.schema, "schema", "", "JSON Schema file, in JSON or YAML, validate checks the config against")
+	fs.StringVar(&o.keyring, "keyring", "", "directory of the base64 AES keys of !decrypt, named by their ids")
+	fs.StringVar(&o.keyID, "key-id", "", "id of the AES key in -keyring that encrypt encrypts with")
+	fs.StringVar(&o.identity, "identity", "", "age identity file that !decrypt decrypts with")
+	fs.StringVar(&o.recipients, "recipient", "", "comma separated age recipients that encrypt encrypts for")
+
+	// flags are accepted before and after the command and its arguments
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return 0
+			}
+			return 2
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			break
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+	if len(positional) == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	ctx := context.Background()
+	command, arguments := positional[0], positional[1:]
+	var err error
+	switch {
+	case command == "get" && len(arguments) == 1:
+		err = get(ctx, o, arguments[0], stdout)
+	case command == "dump" && len(arguments) == 0:
+		err = dump(ctx, o, stdout)
+	case command == "explain" && len(arguments) == 1:
+		err = explain(ctx, o, arguments[0], stdout)
+	case command == "validate" && len(arguments) == 0:
+		err = validate(ctx, o, stdout)
+	case command == "diff" && len(arguments) == 2:
+		var differ bool
+		differ, err = diff(ctx, o, arguments[0], arguments[1], stdout)
+		if err == nil && differ {
+			return 1
+		}
+	case command == "encrypt" && (len(arguments) == 2 || len(arguments) == 3):
+		var value *string
+		if len(arguments) == 3 {
+			// values given as arguments would be left in the shell history and the process list
+			if arguments[2] != "-" {
+				fmt.Fprintln(stderr, "gofigure: encrypt reads the value from stdin, pass - instead of the value")
+				return 2
+			}
+			if value, err = readValue(stdin); err != nil {
+				break
+			}
+		}
+		err = encrypt(ctx, o, arguments[0], arguments[1], value)
+	default:
+		fs.Usage()
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "gofigure: %s\n", err)
+		return 1
+	}
+	return 0
+}
+
+// load loads the config directory with the given profiles active, and the built-in features.
+func (o *options) load(profiles []string, knownProfiles ...string) (*gofigure.Loader, error) {
+	configDir := os.DirFS(o.dir)
+	loader := gofigure.New().WithFeatures(
+		feature.Reference(),
+		feature.Template(),
+		feature.Include(configDir),
+		feature.Env(),
+	)
+	decrypt, err := o.decryptor()
+	if err != nil {
+		return nil, err
+	}
+	if decrypt != nil {
+		loader = loader.WithFeatures(decrypt)
+	}
+
+	known := append(gofigure.ParseProfiles(o.knownProfiles), knownProfiles...)
+	if err := loader.LoadFS(configDir, gofigure.FSProfiles(profiles...), gofigure.FSKnownProfiles(known...)); err != nil {
+		return nil, err
+	}
+	if o.envPrefix != "" {
+		if err := loader.LoadEnv(gofigure.EnvPrefix(o.envPrefix)); err != nil {
+			return nil, err
+		}
+	}
+	return loader, nil
+}
+
+func (o *options) masks() []string {
+	var patterns []string
+	for _, pattern := range strings.Split(o.mask, ",") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns
+}
+
+// masked reports whether the value at path is masked, which it is if any pattern matches it or a path above it.
+func (o *options) masked(path string) bool {
+	for _, pattern := range o.masks() {
+		for prefix := path; prefix != ""; prefix = parentPath(prefix) {
+			if gofigure.MatchPath(pattern, prefix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func parentPath(path string) string {
+	if i := strings.LastIndexAny(path, ".["); i > 0 {
+		return path[:i]
+	}
+	return ""
+}
+
+func (o *options) export(ctx context.Context, loader *gofigure.Loader, path string, w io.Writer) error {
+	options := []gofigure.ExportOption{gofigure.ExportRoot(path), gofigure.ExportMask(o.masks()...)}
+	if o.provenance {
+		options = append(options, gofigure.ExportProvenance())
+	}
+	if o.reveal {
+		options = append(options, gofigure.ExportRevealSensitive())
+	}
+	if o.envPrefix != "" {
+		options = append(options, gofigure.ExportEnvPrefix(o.envPrefix))
+	}
+	return loader.Export(ctx, w, gofigure.ExportFormat(o.format), options...)
+}
+
+func get(ctx context.Context, o *options, path string, w io.Writer) error {
+	loader, err := o.load(gofigure.ParseProfiles(o.profiles))
+	if err != nil {
+		return err
+	}
+	node, err := loader.GetNode(ctx, path)
+	if err != nil {
+		return err
+	}
+	if node == nil {
+		return fmt.Errorf("%s: %w", path, gofigure.ErrPathNotFound)
+	}
+
+	// scalars are printed as they are, so they can be used in scripts
+	if node.Kind() == yaml.ScalarNode && !o.provenance {
+		value := node.Value()
+		if o.masked(path) || node.Sensitive() && !o.reveal {
+			value = gofigure.Redacted
+		}
+		_, err := fmt.Fprintln(w, value)
+		return err
+	}
+	return o.export(ctx, loader, path, w)
+}
+
+func dump(ctx context.Context, o *options, w io.Writer) error {
+	loader, err := o.load(gofigure.ParseProfiles(o.profiles))
+	if err != nil {
+		return err
+	}
+	return o.export(ctx, loader, "", w)
+}
+
+func explain(ctx context.Context, o *options, path string, w io.Writer) error {
+	loader, err := o.load(gofigure.ParseProfiles(o.profiles))
+	if err != nil {
+		return err
+	}
+	e, err := loader.Explain(ctx, path)
+	if err != nil {
+		return err
+	}
+
+	// the raw values of the origins are left out of masked values
+	if o.masked(path) {
+		for i := range e.Origins {
+			e.Origins[i].Value = ""
+		}
+		e.Value = gofigure.NewScalarNode(gofigure.Redacted)
+		e.RawValue = ""
+	}
+	if o.reveal {
+		e.Sensitive = false
+	}
+	_, err = fmt.Fprintln(w, e)
+	return err
+}
+
+func validate(ctx context.Context, o *options, w io.Writer) error {
+	loader, err := o.load(gofigure.ParseProfiles(o.profiles))
+	if err != nil {
+		return err
+	}
+	if err := loader.Validate(ctx); err != nil {
+		return err
+	}
+	if o.schema != "" {
+		contents, err := os.ReadFile(o.schema)
+		if err != nil {
+			return err
+		}
+		s, err := schema.Compile(contents, schema.URI(filepath.ToSlash(o.schema)))
+		if err != nil {
+			return err
+		}
+		if err := s.ValidatePath(ctx, loader, ""); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintln(w, "ok")
+	return err
+}
+
+// diff prints the values that differ from the profiles a to the profiles b, and reports whether there are any.
+func diff(ctx context.Context, o *options, a, b string, w io.Writer) (bool, error) {
+	profilesA, profilesB := gofigure.ParseProfiles(a), gofigure.ParseProfiles(b)
+	known := append(append([]string(nil), profilesA...), profilesB...)
+	loaderA, err := o.load(profilesA, known...)
+	if err != nil {
+		return false, err
+	}
+	loaderB, err := o.load(profilesB, known...)
+	if err != nil {
+		return false, err
+	}
+
+	changes, err := loaderA.Diff(ctx, loaderB)
+	if err != nil {
+		return false, err
+	}
+	for _, change := range changes {
+		before, after := o.diffValue(change.Path, change.Old), o.diffValue(change.Path, change.New)
+		switch {
+		case change.Old == nil:
+			_, err = fmt.Fprintf(w, "+ %s: %s\n", change.Path, after)
+		case change.New == nil:
+			_, err = fmt.Fprintf(w, "- %s: %s\n", change.Path, before)
+		default:
+			_, err = fmt.Fprintf(w, "~ %s: %s -> %s\n", change.Path, before, after)
+		}
+		if err != nil {
+			return false, err
+		}
+	}
+	return len(changes) > 0, nil
+}
+
+// diffValue formats a value on a single line.
+func (o *options) diffValue(path string, node *gofigure.Node) string {
+	if node == nil {
+		return ""
+	}
+	yamlNode := node.ToRedactedYAMLNode()
+	if o.reveal {
+		yamlNode = node.ToYAMLNode()
+	}
+	o.maskYAML(path, yamlNode)
+	if yamlNode.Kind == yaml.ScalarNode {
+		return yamlNode.Value
+	}
+	yamlNode.Style = yaml.FlowStyle
+	b, err := yaml.Marshal(yamlNode)
+	if err != nil {
+		return err.Error()
+	}
+	return strings.TrimSpace(string(b))
+}
+
+// maskYAML masks the scalars under node at path whose paths are masked.
+func (o *options) maskYAML(path string, node *yaml.Node) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			childPath := node.Content[i].Value
+			if path != "" {
+				childPath = path + "." + childPath
+			}
+			o.maskYAML(childPath, node.Content[i+1])
+		}
+	case yaml.SequenceNode:
+		for i, child := range node.Content {
+			o.maskYAML(fmt.Sprintf("%s[%d]", path, i), child)
+		}
+	case yaml.ScalarNode:
+		if o.masked(path) {
+			node.Value, node.Tag, node.Style = gofigure.Redacted, "!!str", 0
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("gofigure", func() {
+	var dir string
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+		files := map[string]string{
+			"app.yaml": `name: gofigure
+port: 8080
+listen: !tpl |
+  :{{ config "app.port" }}
+`,
+			"storage/db.yaml": `host: localhost
+password: hunter2
+`,
+			"prod/app.yaml":        `port: 80`,
+			"prod/storage/db.yaml": `host: db.internal`,
+			"staging/app.yaml":     `debug: true`,
+		}
+		for name, contents := range files {
+			Expect(os.MkdirAll(filepath.Join(dir, filepath.Dir(name)), 0755)).To(BeNil())
+			Expect(os.WriteFile(filepath.Join(dir, name), []byte(contents), 0644)).To(BeNil())
+		}
+	})
+
+	run := func(args ...string) (int, string, string) {
+		var stdout, stderr bytes.Buffer
+		code := run(append([]string{"-dir", dir, "-known-profiles", "prod,staging"}, args...), strings.NewReader(""), &stdout, &stderr)
+		return code, stdout.String(), stderr.String()
+	}
+
+	It("should get a value", func() {
+		code, stdout, _ := run("get", "app.listen", "-profiles", "prod")
+		Expect(code).To(Equal(0))
+		Expect(stdout).To(Equal(":80\n"))
+
+		code, stdout, _ = run("-format", "json", "-mask", "**.password", "get", "storage.db")
+		Expect(code).To(Equal(0))
+		Expect(stdout).To(Equal(`{
+  "host": "localhost",
+  "password": "******"
+}
+`))
+
+		code, _, stderr := run("get", "app.missing")
+		Expect(code).To(Equal(1))
+		Expect(stderr).To(Equal("gofigure: app.missing: path not found\n"))
+	})
+
+	It("should dump the config", func() {
+		code, stdout, _ := run("dump", "-profiles", "prod", "-format", "properties")
+		Expect(code).To(Equal(0))
+		Expect(stdout).To(Equal(`app.name=gofigure
+app.port=80
+app.listen=:80
+storage.db.host=db.internal
+storage.db.password=hunter2
+`))
+	})
+
+	It("should explain a value", func() {
+		code, stdout, _ := run("explain", "app.port", "-profiles", "prod")
+		Expect(code).To(Equal(0))
+		Expect(stdout).To(Equal(`app.port = "80"
+  set by app.yaml:2:7 "8080"
+  set by prod/app.yaml:1:7 "80"
+`))
+
+		code, stdout, _ = run("explain", "storage.db.password", "-mask", "**.password")
+		Expect(code).To(Equal(0))
+		Expect(stdout).To(Equal(`storage.db.password = "******"
+  set by storage/db.yaml:2:11
+`))
+	})
+
+	It("should validate the config", func() {
+		code, stdout, _ := run("validate")
+		Expect(code).To(Equal(0))
+		Expect(stdout).To(Equal("ok\n"))
+
+		Expect(os.WriteFile(filepath.Join(dir, "broken.yaml"), []byte(`value: !ref missing`), 0644)).To(BeNil())
+		code, _, stderr := run("validate")
+		Expect(code).To(Equal(1))
+		Expect(stderr).To(Equal("gofigure: 1:8@broken.yaml: !ref missing not found\n"))
+	})
+
+	It("should validate the config against a schema", func() {
+		schemaFile := filepath.Join(GinkgoT().TempDir(), "schema.yaml")
+		Expect(os.WriteFile(schemaFile, []byte(`
+properties:
+  app:
+    required: [debug]
+    properties:
+      port: {maximum: 8080}
+`), 0644)).To(BeNil())
+
+		code, stdout, _ := run("validate", "-schema", schemaFile, "-profiles", "staging")
+		Expect(code).To(Equal(0))
+		Expect(stdout).To(Equal("ok\n"))
+
+		code, _, stderr := run("validate", "-schema", schemaFile)
+		Expect(code).To(Equal(1))
+		Expect(stderr).To(Equal("gofigure: app.yaml:1:1: app: missing required property \"debug\"\n"))
+	})
+
+	It("should diff two profiles", func() {
+		code, stdout, _ := run("-mask", "storage.db", "diff", "staging", "prod")
+		Expect(code).To(Equal(1))
+		Expect(stdout).To(Equal(`- app.debug: true
+~ app.listen: :8080 -> :80
+~ app.port: 8080 -> 80
+~ storage.db.host: ****** -> ******
+`))
+
+		code, stdout, _ = run("diff", "prod", "prod")
+		Expect(code).To(Equal(0))
+		Expect(stdout).To(BeEmpty())
+	})
+
+	It("should redact sensitive values", func() {
+		Expect(os.WriteFile(filepath.Join(dir, "auth.yaml"), []byte(`token: !sensitive abc123`), 0644)).To(BeNil())
+
+		code, stdout, _ := run("get", "auth.token")
+		Expect(code).To(Equal(0))
+		Expect(stdout).To(Equal("******\n"))
+
+		code, stdout, _ = run("get", "auth", "-format", "json")
+		Expect(code).To(Equal(0))
+		Expect(stdout).To(Equal(`{
+  "token": "******"
+}
+`))
+
+		code, stdout, _ = run("explain", "auth.token")
+		Expect(code).To(Equal(0))
+		Expect(stdout).To(Equal(`auth.token = "******"
+  set by auth.yaml:1:8 "******"
+`))
+
+		code, stdout, _ = run("get", "auth.token", "-reveal")
+		Expect(code).To(Equal(0))
+		Expect(stdout).To(Equal("abc123\n"))
+	})
+
+	It("should reject invalid usage", func() {
+		code, _, stderr := run()
+		Expect(code).To(Equal(2))
+		Expect(stderr).To(ContainSubstring("usage: gofigure"))
+
+		code, _, _ = run("get")
+		Expect(code).To(Equal(2))
+
+		code, _, _ = run("-unknown", "dump")
+		Expect(code).To(Equal(2))
+
+		code, _, stderr = run("dump", "-format", "xml")
+		Expect(code).To(Equal(1))
+		Expect(stderr).To(Equal("gofigure: xml: unsupported format\n"))
+	})
+})
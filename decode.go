@@ -0,0 +1,493 @@
+package gofigure
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DecodeError is a value that cannot be decoded into its target, or that does not satisfy the validate tag of its
+// field. Values that are missing are reported at the mapping they are missing from.
+type DecodeError struct {
+	// Path is the dot path of the value, e.g. app.servers[0].port.
+	Path string
+	// Source, Line and Column are where the value was set, see Node.Source. Line and Column are zero for values not
+	// loaded from files.
+	Source string
+	Line   int
+	Column int
+	Err    error
+}
+
+func (e *DecodeError) Error() string {
+	position := e.Source
+	if e.Line > 0 {
+		position = fmt.Sprintf("%s:%d:%d", e.Source, e.Line, e.Column)
+	}
+	if position == "" {
+		return fmt.Sprintf("%s: %s", e.Path, e.Err)
+	}
+	return fmt.Sprintf("%s: %s: %s", position, e.Path, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+var (
+	yamlUnmarshalerType = reflect.TypeOf((*yaml.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	yamlNodeType        = reflect.TypeOf(yaml.Node{})
+	timeType            = reflect.TypeOf(time.Time{})
+)
+
+// decoder decodes resolved nodes into Go values, the way yaml.v3 does, with decode hooks and the default and validate
+// struct tags on top. Every error is collected.
+type decoder struct {
+	hooks map[reflect.Type]DecodeHook
+	errs  []error
+}
+
+// decode decodes node, the value at path, into target, which must be a non-nil pointer. node is nil if there is no
+// value at path, in which case only the defaults are set, and missing values are reported at position.
+func decode(hooks map[reflect.Type]DecodeHook, node, position *Node, path string, target any) error {
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return fmt.Errorf("unable to decode into %T: not a non-nil pointer", target)
+	}
+
+	d := &decoder{hooks: hooks}
+	if node == nil {
+		if v.Elem().Kind() == reflect.Struct {
+			d.decodeStruct(nil, position, path, v.Elem())
+		}
+	} else {
+		d.decodeValue(node, path, v.Elem())
+	}
+	return errors.Join(d.errs...)
+}
+
+// fail records an error for the value at path, which was set at node.
+func (d *decoder) fail(node *Node, path string, err error) {
+	e := &DecodeError{Path: path, Err: redactError(node, err)}
+	if node != nil {
+		e.Source, e.Line, e.Column = node.Source(), node.line, node.column
+	}
+	d.errs = append(d.errs, e)
+}
+
+func (d *decoder) decodeValue(node *Node, path string, v reflect.Value) {
+	value := resolvedValue(node)
+	t := v.Type()
+	if hook, ok := d.hooks[t]; ok && value.kind == yaml.ScalarNode {
+		d.decodeHook(hook, node, path, v)
+		return
+	}
+	if isTextUnmarshaler(t) && value.kind == yaml.ScalarNode && !isNull(value) {
+		if err := v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value.Value())); err != nil {
+			d.fail(node, path, err)
+		}
+		return
+	}
+	if isDecodedByYAML(t) {
+		d.decodeYAML(node, path, v)
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		if isNull(value) {
+			v.Set(reflect.Zero(t))
+			return
+		}
+		if v.IsNil() {
+			v.Set(reflect.New(t.Elem()))
+		}
+		d.decodeValue(node, path, v.Elem())
+	case reflect.Struct:
+		if isNull(value) {
+			return
+		}
+		if value.kind != yaml.MappingNode {
+			d.fail(node, path, fmt.Errorf("cannot decode %s into %s", kindName(value), t))
+			return
+		}
+		d.decodeStruct(node, node, path, v)
+	case reflect.Slice:
+		if value.kind != yaml.SequenceNode {
+			d.decodeYAML(node, path, v)
+			return
+		}
+		slice := reflect.MakeSlice(t, len(value.sequenceNodes), len(value.sequenceNodes))
+		for i, child := range value.sequenceNodes {
+			d.decodeValue(child, fmt.Sprintf("%s[%d]", path, i), slice.Index(i))
+		}
+		v.Set(slice)
+	case reflect.Array:
+		if value.kind != yaml.SequenceNode || len(value.sequenceNodes) != t.Len() {
+			d.decodeYAML(node, path, v)
+			return
+		}
+		for i, child := range value.sequenceNodes {
+			d.decodeValue(child, fmt.Sprintf("%s[%d]", path, i), v.Index(i))
+		}
+	case reflect.Map:
+		if value.kind != yaml.MappingNode || t.Key().Kind() != reflect.String {
+			d.decodeYAML(node, path, v)
+			return
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeMapWithSize(t, len(value.mappingNodes)))
+		}
+		for _, key := range value.MappingKeys() {
+			elem := reflect.New(t.Elem()).Elem()
+			if existing := v.MapIndex(reflect.ValueOf(key).Convert(t.Key())); existing.IsValid() {
+				elem.Set(existing)
+			}
+			d.decodeValue(value.mappingNodes[key], joinPath(path, key), elem)
+			v.SetMapIndex(reflect.ValueOf(key).Convert(t.Key()), elem)
+		}
+	default:
+		d.decodeYAML(node, path, v)
+	}
+}
+
+// decodeHook decodes a scalar with a decode hook, null is the zero value.
+func (d *decoder) decodeHook(hook DecodeHook, node *Node, path string, v reflect.Value) {
+	value := resolvedValue(node)
+	if isNull(value) {
+		v.Set(reflect.Zero(v.Type()))
+		return
+	}
+	decoded, err := hook.Decode(value)
+	if err != nil {
+		d.fail(node, path, err)
+		return
+	}
+	decodedValue := reflect.ValueOf(decoded)
+	if !decodedValue.IsValid() {
+		v.Set(reflect.Zero(v.Type()))
+		return
+	}
+	if !decodedValue.Type().AssignableTo(v.Type()) {
+		d.fail(node, path, fmt.Errorf("decode hook for %s returned a %s", v.Type(), decodedValue.Type()))
+		return
+	}
+	v.Set(decodedValue)
+}
+
+// decodeYAML decodes a node with yaml.v3, for the types it handles on its own.
+func (d *decoder) decodeYAML(node *Node, path string, v reflect.Value) {
+	err := resolvedValue(node).ToYAMLNode().Decode(v.Addr().Interface())
+	var typeError *yaml.TypeError
+	if errors.As(err, &typeError) {
+		// strip the line numbers, which are those of the resolved value
+		messages := make([]string, len(typeError.Errors))
+		for i, message := range typeError.Errors {
+			if _, after, ok := strings.Cut(message, ": "); ok && strings.HasPrefix(message, "line ") {
+				message = after
+			}
+			messages[i] = message
+		}
+		err = errors.New(strings.Join(messages, ", "))
+	}
+	if err != nil {
+		d.fail(node, path, err)
+	}
+}
+
+// decodeStruct decodes a mapping into a struct, node is nil if the struct is missing from the config, in which case
+// position is the mapping it is missing from. It returns the keys of the fields of the struct.
+func (d *decoder) decodeStruct(node, position *Node, path string, v reflect.Value) map[string]bool {
+	value := resolvedValue(node)
+	if node != nil {
+		position = node
+	}
+
+	var inlineMap reflect.Value
+	fields := map[string]bool{}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		tag := parseFieldTag(field)
+		if tag.skip {
+			continue
+		}
+		fv := v.Field(i)
+
+		if tag.inline {
+			switch fv.Kind() {
+			case reflect.Struct:
+				for name := range d.decodeStruct(node, position, path, fv) {
+					fields[name] = true
+				}
+			case reflect.Map:
+				inlineMap = fv
+			}
+			continue
+		}
+
+		fields[tag.name] = true
+		childPath := joinPath(path, tag.name)
+		var child *Node
+		if value != nil {
+			child = value.mappingNodes[tag.name]
+		}
+
+		present := child != nil && !isNull(resolvedValue(child))
+		failed := false
+		switch {
+		case present:
+			errs := len(d.errs)
+			d.decodeValue(child, childPath, fv)
+			failed = len(d.errs) > errs
+		case tag.hasDefault:
+			defaultNode, err := parseDefault(tag.defaultValue)
+			if err != nil {
+				d.fail(position, childPath, fmt.Errorf("invalid default %q: %w", tag.defaultValue, err))
+				continue
+			}
+			errs := len(d.errs)
+			d.decodeValue(defaultNode, childPath, fv)
+			for _, err := range d.errs[errs:] {
+				// the default is not in the config, so the error is reported at the mapping it is missing from
+				decodeError := err.(*DecodeError)
+				decodeError.Err = fmt.Errorf("invalid default %q: %w", tag.defaultValue, decodeError.Err)
+				decodeError.Source, decodeError.Line, decodeError.Column = "", 0, 0
+				if position != nil {
+					decodeError.Source, decodeError.Line, decodeError.Column = position.Source(), position.line, position.column
+				}
+			}
+			present, failed = true, len(d.errs) > errs
+		case fv.Kind() == reflect.Struct && !isDecodedByYAML(fv.Type()):
+			// the defaults and constraints of the fields of a missing struct still apply
+			d.decodeStruct(nil, position, childPath, fv)
+		}
+
+		// values that failed to decode are not validated
+		if len(tag.rules) > 0 && !failed {
+			at := child
+			if child == nil || isNull(resolvedValue(child)) {
+				at = position
+			}
+			d.validate(fv, tag.rules, present, at, childPath)
+		}
+	}
+
+	if inlineMap.IsValid() && value != nil && value.kind == yaml.MappingNode {
+		if inlineMap.IsNil() {
+			inlineMap.Set(reflect.MakeMap(inlineMap.Type()))
+		}
+		for _, key := range value.MappingKeys() {
+			if fields[key] {
+				continue
+			}
+			elem := reflect.New(inlineMap.Type().Elem()).Elem()
+			d.decodeValue(value.mappingNodes[key], joinPath(path, key), elem)
+			inlineMap.SetMapIndex(reflect.ValueOf(key).Convert(inlineMap.Type().Key()), elem)
+		}
+	}
+	return fields
+}
+
+// validate checks the rules of the validate tag of a field: present is whether the field is set by the config or a
+// default, the other rules only apply if it is.
+func (d *decoder) validate(v reflect.Value, rules []string, present bool, node *Node, path string) {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			present = false
+			break
+		}
+		v = v.Elem()
+	}
+
+	for _, rule := range rules {
+		name, param, _ := strings.Cut(rule, "=")
+		if name == "required" {
+			if !present {
+				d.fail(node, path, errors.New("missing required value"))
+			}
+			continue
+		}
+		if !present {
+			continue
+		}
+
+		var err error
+		switch name {
+		case "min", "max", "len":
+			err = validateSize(v, name, param)
+		case "oneof":
+			err = validateOneOf(v, param)
+		default:
+			err = fmt.Errorf("unknown validation rule %q", name)
+		}
+		if err != nil {
+			d.fail(node, path, err)
+		}
+	}
+}
+
+func validateSize(v reflect.Value, name, param string) error {
+	limit, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return fmt.Errorf("invalid validation rule %s=%s", name, param)
+	}
+
+	var size float64
+	var unit string
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		size = float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		size = float64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		size = v.Float()
+	case reflect.String:
+		size = float64(utf8.RuneCountInString(v.String()))
+		unit = " characters long"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		size = float64(v.Len())
+		unit = " items"
+	default:
+		return fmt.Errorf("validation rule %s does not apply to %s", name, v.Type())
+	}
+
+	limitString := strconv.FormatFloat(limit, 'f', -1, 64)
+	switch {
+	case name == "min" && size < limit:
+		if unit == " items" {
+			return fmt.Errorf("must have at least %s items", limitString)
+		}
+		return fmt.Errorf("must be at least %s%s", limitString, unit)
+	case name == "max" && size > limit:
+		if unit == " items" {
+			return fmt.Errorf("must have at most %s items", limitString)
+		}
+		return fmt.Errorf("must be at most %s%s", limitString, unit)
+	case name == "len" && size != limit:
+		if unit == " items" {
+			return fmt.Errorf("must have exactly %s items", limitString)
+		}
+		return fmt.Errorf("must be exactly %s%s", limitString, unit)
+	}
+	return nil
+}
+
+func validateOneOf(v reflect.Value, param string) error {
+	options := strings.Fields(param)
+	value := fmt.Sprint(v.Interface())
+	for _, option := range options {
+		if value == option {
+			return nil
+		}
+	}
+	return fmt.Errorf("must be one of %s, not %q", strings.Join(options, ", "), value)
+}
+
+type fieldTag struct {
+	name         string
+	skip         bool
+	inline       bool
+	hasDefault   bool
+	defaultValue string
+	rules        []string
+}
+
+// nameTags are the struct tags fields are named by, in order of precedence.
+var nameTags = []string{"yaml", "json", "mapstructure"}
+
+// parseFieldTag reads the name, default and validate tags of a field. Fields are named after their yaml, json or
+// mapstructure tag, the first one set, or their lower cased name, as yaml.v3 does. The inline option of yaml, and the
+// squash and remain options of mapstructure, inline a struct or map.
+func parseFieldTag(field reflect.StructField) fieldTag {
+	tag := fieldTag{name: strings.ToLower(field.Name)}
+	for _, key := range nameTags {
+		value, ok := field.Tag.Lookup(key)
+		if !ok {
+			continue
+		}
+		if value == "-" {
+			tag.skip = true
+			return tag
+		}
+		name, options, _ := strings.Cut(value, ",")
+		if name != "" {
+			tag.name = name
+		}
+		for _, option := range strings.Split(options, ",") {
+			if option == "inline" || option == "squash" || option == "remain" {
+				tag.inline = true
+			}
+		}
+		break
+	}
+
+	tag.defaultValue, tag.hasDefault = field.Tag.Lookup("default")
+	if validate := field.Tag.Get("validate"); validate != "" {
+		tag.rules = strings.Split(validate, ",")
+	}
+	return tag
+}
+
+// parseDefault parses the default of a field as a YAML value, e.g. "8080", "[a, b]" or "{a: 1}".
+func parseDefault(value string) (*Node, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal([]byte(value), &document); err != nil {
+		return nil, err
+	}
+	if len(document.Content) == 0 {
+		return NewScalarNode(""), nil
+	}
+	return NewNode(document.Content[0], NodeSource("default")), nil
+}
+
+// isTextUnmarshaler reports whether values of type t decode from text, and not from YAML, which yaml.v3 only does for
+// strings. time.Time is left to yaml.v3, which reads more timestamp layouts.
+func isTextUnmarshaler(t reflect.Type) bool {
+	pt := reflect.PointerTo(t)
+	return t != timeType && t.Kind() != reflect.Pointer && pt.Implements(textUnmarshalerType) &&
+		!pt.Implements(yamlUnmarshalerType)
+}
+
+// isDecodedByYAML reports whether values of type t are left to yaml.v3, as they decode themselves.
+func isDecodedByYAML(t reflect.Type) bool {
+	if t == yamlNodeType || t.Kind() == reflect.Interface {
+		return true
+	}
+	pt := reflect.PointerTo(t)
+	return t.Implements(yamlUnmarshalerType) || pt.Implements(yamlUnmarshalerType) ||
+		t.Implements(textUnmarshalerType) || pt.Implements(textUnmarshalerType)
+}
+
+func isNull(n *Node) bool {
+	return n == nil || n.kind == yaml.ScalarNode && (n.tag == "!!null" || n.tag == "" && n.style == 0 &&
+		(n.value == "" || n.value == "~" || n.value == "null" || n.value == "Null" || n.value == "NULL"))
+}
+
+func kindName(n *Node) string {
+	switch n.kind {
+	case yaml.MappingNode:
+		return "a mapping"
+	case yaml.SequenceNode:
+		return "a sequence"
+	}
+	return fmt.Sprintf("%q", n.Value())
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,139 @@
+package gofigure
+
+import (
+	"fmt"
+	"math/big"
+	"net"
+	"net/netip"
+	"net/url"
+	"reflect"
+	"regexp"
+	"time"
+)
+
+// DecodeHook decodes scalar nodes into values of a type, for the types yaml.v3 cannot decode from every scalar, or
+// decodes differently than wanted.
+type DecodeHook interface {
+	// Type returns the type the hook decodes into.
+	Type() reflect.Type
+	// Decode decodes the resolved scalar node, the value returned must be assignable to Type.
+	Decode(node *Node) (any, error)
+}
+
+type decodeHookFunc struct {
+	t      reflect.Type
+	decode func(node *Node) (any, error)
+}
+
+func (h *decodeHookFunc) Type() reflect.Type {
+	return h.t
+}
+
+func (h *decodeHookFunc) Decode(node *Node) (any, error) {
+	return h.decode(node)
+}
+
+// DecodeHookFunc returns a hook decoding scalar nodes into a T.
+func DecodeHookFunc[T any](decode func(node *Node) (T, error)) DecodeHook {
+	return &decodeHookFunc{
+		t: reflect.TypeOf((*T)(nil)).Elem(),
+		decode: func(node *Node) (any, error) {
+			return decode(node)
+		},
+	}
+}
+
+// defaultDecodeHooks are registered by New, they can be replaced by registering a hook for the same type.
+func defaultDecodeHooks() []DecodeHook {
+	return []DecodeHook{
+		DecodeHookFunc(func(node *Node) (time.Duration, error) {
+			d, err := time.ParseDuration(node.Value())
+			if err == nil {
+				return d, nil
+			}
+			// integers are nanoseconds, as yaml.v3 decodes them
+			var nanoseconds int64
+			if yamlNode := node.ToYAMLNode(); yamlNode.ShortTag() == "!!int" && yamlNode.Decode(&nanoseconds) == nil {
+				return time.Duration(nanoseconds), nil
+			}
+			return 0, fmt.Errorf("invalid duration %q", node.Value())
+		}),
+		DecodeHookFunc(func(node *Node) (*time.Location, error) {
+			location, err := time.LoadLocation(node.Value())
+			if err != nil {
+				return nil, fmt.Errorf("invalid time zone %q", node.Value())
+			}
+			return location, nil
+		}),
+		DecodeHookFunc(func(node *Node) (time.Location, error) {
+			location, err := time.LoadLocation(node.Value())
+			if err != nil {
+				return time.Location{}, fmt.Errorf("invalid time zone %q", node.Value())
+			}
+			return *location, nil
+		}),
+		DecodeHookFunc(func(node *Node) (net.IP, error) {
+			ip := net.ParseIP(node.Value())
+			if ip == nil {
+				return nil, fmt.Errorf("invalid IP address %q", node.Value())
+			}
+			return ip, nil
+		}),
+		DecodeHookFunc(func(node *Node) (net.IPNet, error) {
+			_, ipNet, err := net.ParseCIDR(node.Value())
+			if err != nil {
+				return net.IPNet{}, fmt.Errorf("invalid CIDR %q", node.Value())
+			}
+			return *ipNet, nil
+		}),
+		DecodeHookFunc(func(node *Node) (netip.Addr, error) {
+			addr, err := netip.ParseAddr(node.Value())
+			if err != nil {
+				return netip.Addr{}, fmt.Errorf("invalid IP address %q", node.Value())
+			}
+			return addr, nil
+		}),
+		DecodeHookFunc(func(node *Node) (netip.AddrPort, error) {
+			addrPort, err := netip.ParseAddrPort(node.Value())
+			if err != nil {
+				return netip.AddrPort{}, fmt.Errorf("invalid IP address and port %q", node.Value())
+			}
+			return addrPort, nil
+		}),
+		DecodeHookFunc(func(node *Node) (netip.Prefix, error) {
+			prefix, err := netip.ParsePrefix(node.Value())
+			if err != nil {
+				return netip.Prefix{}, fmt.Errorf("invalid IP prefix %q", node.Value())
+			}
+			return prefix, nil
+		}),
+		DecodeHookFunc(func(node *Node) (url.URL, error) {
+			u, err := url.Parse(node.Value())
+			if err != nil {
+				return url.URL{}, fmt.Errorf("invalid URL %q", node.Value())
+			}
+			return *u, nil
+		}),
+		DecodeHookFunc(func(node *Node) (*regexp.Regexp, error) {
+			re, err := regexp.Compile(node.Value())
+			if err != nil {
+				return nil, fmt.Errorf("invalid regular expression %q: %w", node.Value(), err)
+			}
+			return re, nil
+		}),
+		DecodeHookFunc(func(node *Node) (big.Int, error) {
+			var i big.Int
+			if _, ok := i.SetString(node.Value(), 0); !ok {
+				return big.Int{}, fmt.Errorf("invalid integer %q", node.Value())
+			}
+			return i, nil
+		}),
+		DecodeHookFunc(func(node *Node) (big.Float, error) {
+			f, _, err := big.ParseFloat(node.Value(), 10, 0, big.ToNearestEven)
+			if err != nil {
+				return big.Float{}, fmt.Errorf("invalid number %q", node.Value())
+			}
+			return *f, nil
+		}),
+	}
+}
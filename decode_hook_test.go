@@ -0,0 +1,161 @@
+package gofigure
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"net"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type level int
+
+func (l *level) UnmarshalText(text []byte) error {
+	switch strings.ToLower(string(text)) {
+	case "debug", "0":
+		*l = 0
+	case "info", "1":
+		*l = 1
+	default:
+		return errors.New("unknown level " + string(text))
+	}
+	return nil
+}
+
+var _ = Describe("DecodeHook", func() {
+	ctx := context.Background()
+
+	It("should decode rich types", func() {
+		loader := New()
+		Expect(loader.Load("app.yaml", []byte(`timeout: 1m
+zone: Europe/Paris
+ip: 10.0.0.1
+network: 10.0.0.0/8
+addr: ::1
+prefix: 192.168.0.0/16
+endpoint: https://example.com/api?v=1
+pattern: ^[a-z]+$
+big: 123456789012345678901234567890
+level: 1
+levels: [debug, info]
+optional: ~`))).To(BeNil())
+
+		var config struct {
+			Timeout  time.Duration   `yaml:"timeout"`
+			Zone     *time.Location  `yaml:"zone"`
+			IP       net.IP          `yaml:"ip"`
+			Network  net.IPNet       `yaml:"network"`
+			Addr     netip.Addr      `yaml:"addr"`
+			Prefix   netip.Prefix    `yaml:"prefix"`
+			Endpoint *url.URL        `yaml:"endpoint"`
+			Pattern  *regexp.Regexp  `yaml:"pattern"`
+			Big      big.Int         `yaml:"big"`
+			Level    level           `yaml:"level"`
+			Levels   []level         `yaml:"levels"`
+			Optional *url.URL        `yaml:"optional"`
+			Default  time.Duration   `yaml:"default" default:"5s"`
+			Fallback *regexp.Regexp  `yaml:"fallback"`
+			Mapping  map[string]bool `yaml:"mapping"`
+		}
+		Expect(loader.Get(ctx, "app", &config)).To(BeNil())
+		Expect(config.Timeout).To(Equal(time.Minute))
+		Expect(config.Zone.String()).To(Equal("Europe/Paris"))
+		Expect(config.IP.String()).To(Equal("10.0.0.1"))
+		Expect(config.Network.String()).To(Equal("10.0.0.0/8"))
+		Expect(config.Addr).To(Equal(netip.MustParseAddr("::1")))
+		Expect(config.Prefix).To(Equal(netip.MustParsePrefix("192.168.0.0/16")))
+		Expect(config.Endpoint.Host).To(Equal("example.com"))
+		Expect(config.Endpoint.Query().Get("v")).To(Equal("1"))
+		Expect(config.Pattern.MatchString("abc")).To(BeTrue())
+		Expect(config.Big.String()).To(Equal("123456789012345678901234567890"))
+		Expect(config.Level).To(Equal(level(1)))
+		Expect(config.Levels).To(Equal([]level{0, 1}))
+		Expect(config.Optional).To(BeNil())
+		Expect(config.Default).To(Equal(5 * time.Second))
+		Expect(config.Fallback).To(BeNil())
+	})
+
+	It("should decode integer durations as nanoseconds", func() {
+		loader := New()
+		Expect(loader.Load("app.yaml", []byte(`timeout: 1000000000
+quoted: "1000000000"`))).To(BeNil())
+		Expect(loader.GetDuration(ctx, "app.timeout")).To(Equal(time.Second))
+		var quoted time.Duration
+		Expect(loader.Get(ctx, "app.quoted", &quoted)).To(MatchError(ContainSubstring(`invalid duration "1000000000"`)))
+	})
+
+	It("should report values hooks cannot decode", func() {
+		loader := New()
+		Expect(loader.Load("app.yaml", []byte(`ip: localhost
+pattern: "[a-"
+level: trace`))).To(BeNil())
+		var config struct {
+			IP      net.IP         `yaml:"ip"`
+			Pattern *regexp.Regexp `yaml:"pattern"`
+			Level   level          `yaml:"level"`
+		}
+		err := loader.Get(ctx, "app", &config)
+		Expect(err).NotTo(BeNil())
+		Expect(err.Error()).To(Equal(`app.yaml:1:5: app.ip: invalid IP address "localhost"
+app.yaml:2:10: app.pattern: invalid regular expression "[a-": error parsing regexp: missing closing ]: ` + "`[a-`" + `
+app.yaml:3:8: app.level: unknown level trace`))
+	})
+
+	It("should decode with registered hooks", func() {
+		type celsius float64
+		loader := New().WithDecodeHooks(
+			DecodeHookFunc(func(node *Node) (celsius, error) {
+				value := strings.TrimSuffix(node.Value(), "°C")
+				var c float64
+				if err := NewScalarNode(value).ToYAMLNode().Decode(&c); err != nil {
+					return 0, errors.New("invalid temperature")
+				}
+				return celsius(c), nil
+			}),
+			DecodeHookFunc(func(node *Node) (time.Duration, error) {
+				var seconds int
+				err := node.ToYAMLNode().Decode(&seconds)
+				return time.Duration(seconds) * time.Second, err
+			}),
+		)
+		Expect(loader.Load("app.yaml", []byte(`max: 21.5°C
+timeout: 30
+temperatures: [10°C, 12°C]`))).To(BeNil())
+
+		Expect(Get[celsius](ctx, loader, "app.max")).To(Equal(celsius(21.5)))
+		Expect(Get[[]celsius](ctx, loader, "app.temperatures")).To(Equal([]celsius{10, 12}))
+		Expect(loader.GetDuration(ctx, "app.timeout")).To(Equal(30 * time.Second))
+	})
+
+	It("should name fields after json and mapstructure tags", func() {
+		loader := New()
+		Expect(loader.Load("app.yaml", []byte(`server_name: api
+listen_port: 8080
+extra: true
+other: false`))).To(BeNil())
+
+		type Base struct {
+			Name string `json:"server_name,omitempty"`
+		}
+		var config struct {
+			Base    `mapstructure:",squash"`
+			Port    int             `mapstructure:"listen_port" validate:"min=1"`
+			Ignored string          `json:"-" default:"ignored"`
+			Both    string          `yaml:"extra" json:"both"`
+			Remain  map[string]bool `mapstructure:",remain"`
+		}
+		Expect(loader.Get(ctx, "app", &config)).To(BeNil())
+		Expect(config.Name).To(Equal("api"))
+		Expect(config.Port).To(Equal(8080))
+		Expect(config.Ignored).To(BeEmpty())
+		Expect(config.Both).To(Equal("true"))
+		Expect(config.Remain).To(Equal(map[string]bool{"other": false}))
+	})
+})
@@ -0,0 +1,185 @@
+package gofigure
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("decode", func() {
+	type Database struct {
+		Host string `yaml:"host" default:"localhost"`
+		Port int    `yaml:"port" default:"5432" validate:"min=1,max=65535"`
+		User string `yaml:"user" validate:"required"`
+	}
+
+	type Server struct {
+		Name     string            `yaml:"name" validate:"required,min=2"`
+		Port     int               `yaml:"port" default:"8080" validate:"required,min=1,max=65535"`
+		Mode     string            `yaml:"mode" default:"release" validate:"oneof=debug release test"`
+		Tags     []string          `yaml:"tags" default:"[a, b]" validate:"max=3"`
+		Timeout  time.Duration     `yaml:"timeout" default:"5s"`
+		Labels   map[string]string `yaml:"labels"`
+		Database Database          `yaml:"database"`
+		Replica  *Database         `yaml:"replica"`
+		Ignored  string            `yaml:"-" default:"ignored"`
+	}
+
+	load := func(content string) *Loader {
+		loader := New()
+		Expect(loader.Load("app.yaml", []byte(content))).To(BeNil())
+		return loader
+	}
+
+	It("should set defaults of missing values", func() {
+		var server Server
+		Expect(load(`name: api
+database:
+  user: admin`).Get(context.Background(), "app", &server)).To(BeNil())
+		Expect(server).To(Equal(Server{
+			Name:     "api",
+			Port:     8080,
+			Mode:     "release",
+			Tags:     []string{"a", "b"},
+			Timeout:  5 * time.Second,
+			Database: Database{Host: "localhost", Port: 5432, User: "admin"},
+		}))
+	})
+
+	It("should set defaults of null values", func() {
+		var server Server
+		Expect(load(`name: api
+port: ~
+database:
+  user: admin`).Get(context.Background(), "app", &server)).To(BeNil())
+		Expect(server.Port).To(Equal(8080))
+	})
+
+	It("should keep values over defaults", func() {
+		var server Server
+		Expect(load(`name: api
+port: 9090
+mode: debug
+tags: [c]
+timeout: 1m
+labels:
+  team: core
+database:
+  host: db
+  port: 6432
+  user: admin
+replica:
+  user: reader`).Get(context.Background(), "app", &server)).To(BeNil())
+		Expect(server).To(Equal(Server{
+			Name:     "api",
+			Port:     9090,
+			Mode:     "debug",
+			Tags:     []string{"c"},
+			Timeout:  time.Minute,
+			Labels:   map[string]string{"team": "core"},
+			Database: Database{Host: "db", Port: 6432, User: "admin"},
+			Replica:  &Database{Host: "localhost", Port: 5432, User: "reader"},
+		}))
+	})
+
+	It("should report invalid values at their position", func() {
+		var server Server
+		err := load(`name: a
+port: 70000
+mode: prod
+tags: [a, b, c, d]
+database:
+  user: admin`).Get(context.Background(), "app", &server)
+		Expect(err).NotTo(BeNil())
+		Expect(err.Error()).To(Equal(`app.yaml:1:7: app.name: must be at least 2 characters long
+app.yaml:2:7: app.port: must be at most 65535
+app.yaml:3:7: app.mode: must be one of debug, release, test, not "prod"
+app.yaml:4:7: app.tags: must have at most 3 items`))
+
+		var decodeError *DecodeError
+		Expect(errors.As(err, &decodeError)).To(BeTrue())
+		Expect(decodeError.Path).To(Equal("app.name"))
+		Expect(decodeError.Source).To(Equal("app.yaml"))
+		Expect(decodeError.Line).To(Equal(1))
+		Expect(decodeError.Column).To(Equal(7))
+	})
+
+	It("should report missing values at their parent mapping", func() {
+		var server Server
+		err := load(`port: 80
+database:
+  host: db`).Get(context.Background(), "app", &server)
+		Expect(err).NotTo(BeNil())
+		Expect(err.Error()).To(Equal(`app.yaml:1:1: app.name: missing required value
+app.yaml:3:3: app.database.user: missing required value`))
+	})
+
+	It("should report missing values of missing mappings at the closest mapping", func() {
+		var database Database
+		err := load(`name: api`).Get(context.Background(), "app.database", &database)
+		Expect(err).NotTo(BeNil())
+		Expect(err.Error()).To(Equal(`app.yaml:1:1: app.database.user: missing required value`))
+		Expect(database).To(Equal(Database{Host: "localhost", Port: 5432}))
+	})
+
+	It("should report values that cannot be decoded", func() {
+		var server Server
+		err := load(`name: api
+port: eighty
+database:
+  user: admin`).Get(context.Background(), "app", &server)
+		Expect(err).NotTo(BeNil())
+		Expect(err.Error()).To(Equal("app.yaml:2:7: app.port: cannot unmarshal !!str `eighty` into int"))
+	})
+
+	It("should report invalid defaults", func() {
+		var config struct {
+			Port int `yaml:"port" default:"eighty"`
+		}
+		err := load(`name: api`).Get(context.Background(), "app", &config)
+		Expect(err).NotTo(BeNil())
+		Expect(err.Error()).To(Equal("app.yaml:1:1: app.port: invalid default \"eighty\": cannot unmarshal !!str `eighty` into int"))
+	})
+
+	It("should report unknown rules", func() {
+		var config struct {
+			Name string `yaml:"name" validate:"email"`
+		}
+		err := load(`name: api`).Get(context.Background(), "app", &config)
+		Expect(err).NotTo(BeNil())
+		Expect(err.Error()).To(Equal(`app.yaml:1:7: app.name: unknown validation rule "email"`))
+	})
+
+	It("should decode inline fields", func() {
+		type Base struct {
+			Name string `yaml:"name" validate:"required"`
+		}
+		var config struct {
+			Base  `yaml:",inline"`
+			Port  int            `yaml:"port" default:"80"`
+			Extra map[string]int `yaml:",inline"`
+		}
+		Expect(load(`name: api
+a: 1
+b: 2`).Get(context.Background(), "app", &config)).To(BeNil())
+		Expect(config.Name).To(Equal("api"))
+		Expect(config.Port).To(Equal(80))
+		Expect(config.Extra).To(Equal(map[string]int{"a": 1, "b": 2}))
+	})
+
+	It("should decode resolved values", func() {
+		loader := New().WithFeatures(refFeature)
+		Expect(loader.Load("app.yaml", []byte(`port: 9090
+server:
+  name: api
+  port: !ref app.port
+  database:
+    user: admin`))).To(BeNil())
+		var server Server
+		Expect(loader.Get(context.Background(), "app.server", &server)).To(BeNil())
+		Expect(server.Port).To(Equal(9090))
+	})
+})
@@ -0,0 +1,101 @@
+package gofigure
+
+import (
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Decoder turns the contents of a file into a node tree.
+type Decoder interface {
+	// Formats returns the file extensions (e.g. ".yaml") and MIME types (e.g. "application/yaml") handled by the decoder.
+	Formats() []string
+	Decode(contents []byte, options ...NodeOption) (*Node, error)
+}
+
+type DecodeFunc func(contents []byte, options ...NodeOption) (*Node, error)
+
+type decoderFunc struct {
+	formats []string
+	decode  DecodeFunc
+}
+
+func (d *decoderFunc) Formats() []string {
+	return d.formats
+}
+
+func (d *decoderFunc) Decode(contents []byte, options ...NodeOption) (*Node, error) {
+	return d.decode(contents, options...)
+}
+
+func DecoderFunc(decode DecodeFunc, formats ...string) Decoder {
+	return &decoderFunc{
+		formats: formats,
+		decode:  decode,
+	}
+}
+
+// builtinDecoders is the registry of a Loader no decoder is registered to, it is never modified.
+var builtinDecoders = func() map[string]Decoder {
+	registry := map[string]Decoder{}
+	for _, decoder := range []Decoder{YAMLDecoder(), JSONDecoder(), TOMLDecoder()} {
+		for _, format := range decoder.Formats() {
+			registry[normalizeFormat(format)] = decoder
+		}
+	}
+	return registry
+}()
+
+func YAMLDecoder() Decoder {
+	return DecoderFunc(decodeYAML, ".yaml", ".yml", "application/yaml", "application/x-yaml", "text/yaml")
+}
+
+func JSONDecoder() Decoder {
+	return DecoderFunc(decodeJSON, ".json", ".jsonc", ".json5", "application/json", "application/json5")
+}
+
+func TOMLDecoder() Decoder {
+	return DecoderFunc(decodeTOML, ".toml", "application/toml")
+}
+
+func decodeYAML(contents []byte, options ...NodeOption) (*Node, error) {
+	var yamlNode yaml.Node
+	if err := yaml.Unmarshal(contents, &yamlNode); err != nil {
+		// the aliases may refer to the anchors of other files
+		o := &nodeOptions{}
+		for _, option := range options {
+			option.apply(o)
+		}
+		if len(o.anchors) == 0 || !unmarshalWithAnchors(contents, o.anchors, &yamlNode) {
+			return nil, err
+		}
+	}
+
+	// an empty file has no document at all
+	if len(yamlNode.Content) == 0 {
+		return NewMappingNode(map[string]*Node{}, options...), nil
+	}
+
+	// root node is a document node, and the first child is a map holds all the values
+	node := NewNode(yamlNode.Content[0], options...)
+	if node.headComment == "" {
+		node.headComment = yamlNode.HeadComment
+	}
+	if node.footComment == "" {
+		node.footComment = yamlNode.FootComment
+	}
+	return node, nil
+}
+
+// normalizeFormat returns the registry key of a format: extensions are lower-cased and get a leading dot (so both
+// "yaml" and ".YAML" are accepted), MIME types are lower-cased and stripped of their parameters.
+func normalizeFormat(format string) string {
+	format = strings.ToLower(strings.TrimSpace(format))
+	if i := strings.Index(format, ";"); i >= 0 {
+		format = strings.TrimSpace(format[:i])
+	}
+	if format != "" && !strings.HasPrefix(format, ".") && !strings.Contains(format, "/") {
+		format = "." + format
+	}
+	return format
+}
@@ -0,0 +1,91 @@
+package gofigure
+
+import (
+	"context"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// decodeDotenv is a minimal decoder for KEY=VALUE lines
+func decodeDotenv(contents []byte, options ...NodeOption) (*Node, error) {
+	values := map[string]*Node{}
+	for i, line := range strings.Split(string(contents), "\n") {
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		node := NewScalarNode(strings.TrimSpace(value), NodeMappingKey(key))
+		node.line = i + 1
+		node.column = len(key) + 2
+		values[key] = node
+	}
+	node := NewMappingNode(values, options...)
+	for _, value := range values {
+		value.parent = node
+	}
+	return node, nil
+}
+
+var _ = Describe("Decoder", func() {
+	It("should decode with registered decoders", func() {
+		loader := New().WithDecoders(DecoderFunc(decodeDotenv, ".env", "text/x-dotenv"))
+		Expect(loader.Load("app.yaml", []byte(`host: localhost
+port: 8080`))).To(BeNil())
+		Expect(loader.Load("app.env", []byte("HOST=remote-address\nDEBUG=true"))).To(BeNil())
+
+		var app struct {
+			Host  string `yaml:"host"`
+			Port  int    `yaml:"port"`
+			Debug bool   `yaml:"debug"`
+		}
+		Expect(loader.Get(context.Background(), "app", &app)).To(BeNil())
+		Expect(app.Host).To(Equal("remote-address"))
+		Expect(app.Port).To(Equal(8080))
+		Expect(app.Debug).To(BeTrue())
+
+		hostNode, err := loader.GetNode(context.Background(), "app.host")
+		Expect(err).To(BeNil())
+		Expect(hostNode.Filepath()).To(Equal("app.env"))
+		Expect(hostNode.Line()).To(Equal(1))
+	})
+
+	It("should load with a MIME type", func() {
+		loader := New()
+		Expect(loader.LoadAs("app", "application/json; charset=utf-8", []byte(`{"port": 80}`))).To(BeNil())
+		Expect(loader.LoadAs("storage/db.conf", "toml", []byte(`port = 3306`))).To(BeNil())
+
+		var port int
+		Expect(loader.Get(context.Background(), "app.port", &port)).To(BeNil())
+		Expect(port).To(Equal(80))
+		Expect(loader.Get(context.Background(), "storage.db.port", &port)).To(BeNil())
+		Expect(port).To(Equal(3306))
+	})
+
+	It("should fail with unsupported format", func() {
+		loader := New()
+		Expect(loader.LoadAs("app.ini", ".ini", []byte(`port = 80`))).To(MatchError(ErrUnsupportedFormat))
+	})
+
+	It("should load unknown extensions as yaml", func() {
+		loader := New()
+		Expect(loader.Load("app.conf", []byte(`port: 80`))).To(BeNil())
+
+		var port int
+		Expect(loader.Get(context.Background(), "app.port", &port)).To(BeNil())
+		Expect(port).To(Equal(80))
+	})
+})
+
+var _ = DescribeTable("Decoder normalize format", func(format, expected string) {
+	Expect(normalizeFormat(format)).To(Equal(expected))
+},
+	Entry("extension", ".yaml", ".yaml"),
+	Entry("upper case extension", ".YAML", ".yaml"),
+	Entry("extension without dot", "toml", ".toml"),
+	Entry("MIME type", "application/json", "application/json"),
+	Entry("MIME type with parameters", "Application/JSON; charset=utf-8", "application/json"),
+	Entry("empty", "", ""),
+)
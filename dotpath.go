@@ -91,3 +91,40 @@ func ParseDotPath(path string) ([]*DotPath, error) {
 
 	return paths, nil
 }
+
+// MatchPath reports whether path matches pattern, a dot path in which "*" matches any single key or index and "**"
+// matches any number of them.
+func MatchPath(pattern, path string) bool {
+	patterns, err := ParseDotPath(pattern)
+	if err != nil {
+		return false
+	}
+	paths, err := ParseDotPath(path)
+	if err != nil {
+		return false
+	}
+	return matchDotPaths(patterns, paths)
+}
+
+func matchDotPaths(patterns, paths []*DotPath) bool {
+	if len(patterns) == 0 {
+		return len(paths) == 0
+	}
+
+	p := patterns[0]
+	switch {
+	case p.Key == "**":
+		for i := 0; i <= len(paths); i++ {
+			if matchDotPaths(patterns[1:], paths[i:]) {
+				return true
+			}
+		}
+		return false
+	case len(paths) == 0:
+		return false
+	case p.Key == "*":
+	case p.Key != paths[0].Key || (p.Key == "" && p.Index != paths[0].Index):
+		return false
+	}
+	return matchDotPaths(patterns[1:], paths[1:])
+}
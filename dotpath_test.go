@@ -45,3 +45,19 @@ var _ = DescribeTable("Dotpath failed scenarios", func(path string) {
 func dotPathEntry(path string) TableEntry {
 	return Entry(fmt.Sprintf("path %q", path), path)
 }
+
+var _ = DescribeTable("Path patterns", func(pattern, path string, matched bool) {
+	Expect(MatchPath(pattern, path)).To(Equal(matched))
+},
+	Entry(nil, "a.b", "a.b", true),
+	Entry(nil, "a.b", "a.c", false),
+	Entry(nil, "a.*", "a.b", true),
+	Entry(nil, "a.*", "a.b.c", false),
+	Entry(nil, "a.*.c", "a[0].c", true),
+	Entry(nil, "a[1]", "a[0]", false),
+	Entry(nil, "**.password", "db.password", true),
+	Entry(nil, "**.password", "password", true),
+	Entry(nil, "**", "a.b[0].c", true),
+	Entry(nil, "a.**.c", "a.c", true),
+	Entry(nil, "a.**.c", "a.b.d", false),
+)
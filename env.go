@@ -0,0 +1,143 @@
+package gofigure
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const envSourcePrefix = "env:"
+
+// LoadEnv loads environment variables as an overlay on top of the files loaded so far, e.g. with the prefix "APP",
+// APP__STORAGE__DB__HOST overrides storage.db.host. Numeric segments address sequence elements, so APP__SERVERS__0__HOST
+// only overrides the host of the first server. Values are typed the same way plain YAML scalars are. The environment is
+// read again on reload.
+func (l *Loader) LoadEnv(options ...EnvOption) error {
+	o := defaultEnvOptions()
+	for i := range options {
+		options[i].apply(o)
+	}
+
+	return l.addSource(func(into *Loader) error {
+		envNode, err := newEnvNode(o)
+		if err != nil {
+			return fmt.Errorf("unable to load environment: %w", errors.Join(err, ErrConfigParseError))
+		}
+		if err := into.merge(envNode); err != nil {
+			return fmt.Errorf("unable to merge environment: %w", errors.Join(err, ErrConfigParseError))
+		}
+		return nil
+	}, func() (string, error) {
+		if o.environ != nil {
+			return "", nil
+		}
+		return strings.Join(os.Environ(), "\x00"), nil
+	})
+}
+
+func newEnvNode(o *envOptions) (*Node, error) {
+	prefix := ""
+	if o.prefix != "" {
+		prefix = o.prefix + o.separator
+	}
+
+	environ := o.environ
+	if environ == nil {
+		environ = os.Environ()
+	}
+	environ = append([]string(nil), environ...)
+	sort.Strings(environ)
+
+	root := NewMappingNode(map[string]*Node{}, NodeSource("env"))
+	for _, env := range environ {
+		name, value, ok := strings.Cut(env, "=")
+		if !ok || !strings.HasPrefix(name, prefix) || name == prefix {
+			continue
+		}
+
+		segments := strings.Split(strings.TrimPrefix(name, prefix), o.separator)
+		if err := setEnvValue(root, name, segments, value, o); err != nil {
+			return nil, err
+		}
+	}
+	return root, nil
+}
+
+func setEnvValue(root *Node, name string, segments []string, value string, o *envOptions) error {
+	paths := make([]*DotPath, len(segments))
+	for i, segment := range segments {
+		if segment == "" {
+			return fmt.Errorf("%s: empty path segment", name)
+		}
+		if index, err := strconv.Atoi(segment); err == nil && index >= 0 {
+			paths[i] = &DotPath{Index: index}
+		} else {
+			paths[i] = &DotPath{Key: o.keyMapper(segment)}
+		}
+	}
+
+	if err := setOverlayValue(root, paths, value, envSourcePrefix+name); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+// encodeDotenv writes an exported node tree as a dotenv file, a variable per scalar named the way LoadEnv reads them.
+func encodeDotenv(w io.Writer, node *Node, o *exportOptions) error {
+	var buf bytes.Buffer
+	err := eachScalar(node, nil, func(segments []string, node *Node) error {
+		v, err := scalarValue(node)
+		if err != nil {
+			return err
+		}
+		value := ""
+		if v != nil {
+			value = node.value
+		}
+
+		if o.envPrefix != "" {
+			segments = append([]string{o.envPrefix}, segments...)
+		}
+		name := strings.Join(segments, o.envSeparator)
+		name = strings.Map(func(r rune) rune {
+			if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' {
+				return unicode.ToUpper(r)
+			}
+			return '_'
+		}, name)
+
+		if o.provenance {
+			buf.WriteString("# " + provenanceComment(node) + "\n")
+		}
+		buf.WriteString(name + "=" + dotenvValue(value) + "\n")
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// dotenvValue double quotes a value unless it only has characters that need no quoting.
+func dotenvValue(value string) string {
+	plain := true
+	for _, r := range value {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("_-.,:/@+", r)) {
+			plain = false
+			break
+		}
+	}
+	if plain {
+		return value
+	}
+
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "`", "\\`", "\n", `\n`, "\r", `\r`)
+	return `"` + replacer.Replace(value) + `"`
+}
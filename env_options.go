@@ -0,0 +1,57 @@
+package gofigure
+
+import (
+	"strings"
+)
+
+type envOptions struct {
+	prefix    string
+	separator string
+	keyMapper func(string) string
+	environ   []string
+}
+
+func defaultEnvOptions() *envOptions {
+	return &envOptions{
+		separator: "__",
+		keyMapper: strings.ToLower,
+	}
+}
+
+type EnvOption interface {
+	apply(*envOptions)
+}
+
+type envOptionFunc func(*envOptions)
+
+func (f envOptionFunc) apply(o *envOptions) {
+	f(o)
+}
+
+// EnvPrefix only loads variables starting with prefix followed by the separator, e.g. "APP" for APP__PORT.
+func EnvPrefix(prefix string) EnvOption {
+	return envOptionFunc(func(o *envOptions) {
+		o.prefix = prefix
+	})
+}
+
+// EnvSeparator sets the separator between path segments, "__" by default.
+func EnvSeparator(separator string) EnvOption {
+	return envOptionFunc(func(o *envOptions) {
+		o.separator = separator
+	})
+}
+
+// EnvKeyMapper maps each path segment of a variable name to a key, strings.ToLower by default.
+func EnvKeyMapper(mapper func(string) string) EnvOption {
+	return envOptionFunc(func(o *envOptions) {
+		o.keyMapper = mapper
+	})
+}
+
+// EnvEnviron reads variables from environ, in the form of "KEY=value", instead of os.Environ.
+func EnvEnviron(environ []string) EnvOption {
+	return envOptionFunc(func(o *envOptions) {
+		o.environ = environ
+	})
+}
@@ -0,0 +1,161 @@
+package gofigure
+
+import (
+	"context"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Env", func() {
+	It("should override with environment variables", func() {
+		loader := New()
+		Expect(loader.Load("storage/db.yaml", []byte(`host: localhost
+port: 3306
+user: root`))).To(BeNil())
+		Expect(loader.LoadEnv(
+			EnvPrefix("APP"),
+			EnvEnviron([]string{
+				"APP__STORAGE__DB__HOST=remote-address",
+				"APP__STORAGE__DB__PORT=3307",
+				"APP__STORAGE__DB__DEBUG=true",
+				"APP__STORAGE__DB__PASSWORD=007",
+				"APP__STORAGE__DB__OPTIONS=a: b",
+				"OTHER__STORAGE__DB__USER=admin",
+				"APP=ignored",
+			}),
+		)).To(BeNil())
+
+		var db struct {
+			Host     string `yaml:"host"`
+			Port     int    `yaml:"port"`
+			User     string `yaml:"user"`
+			Debug    bool   `yaml:"debug"`
+			Password string `yaml:"password"`
+			Options  string `yaml:"options"`
+		}
+		Expect(loader.Get(context.Background(), "storage.db", &db)).To(BeNil())
+		Expect(db.Host).To(Equal("remote-address"))
+		Expect(db.Port).To(Equal(3307))
+		Expect(db.User).To(Equal("root"))
+		Expect(db.Debug).To(BeTrue())
+		Expect(db.Password).To(Equal("007"))
+		Expect(db.Options).To(Equal("a: b"))
+
+		portNode, err := loader.GetNode(context.Background(), "storage.db.port")
+		Expect(err).To(BeNil())
+		Expect(portNode.Tag()).To(Equal("!!int"))
+		Expect(portNode.Source()).To(Equal("env:APP__STORAGE__DB__PORT"))
+		Expect(portNode.Filepath()).To(Equal(""))
+		Expect(portNode.Keypath()).To(Equal("storage.db.port"))
+
+		userNode, err := loader.GetNode(context.Background(), "storage.db.user")
+		Expect(err).To(BeNil())
+		Expect(userNode.Source()).To(Equal("storage/db.yaml"))
+	})
+
+	It("should override sequence elements by index", func() {
+		loader := New()
+		Expect(loader.Load("app.yaml", []byte(`servers:
+- host: a
+  port: 1
+- host: b
+  port: 2`))).To(BeNil())
+		Expect(loader.LoadEnv(
+			EnvPrefix("APP"),
+			EnvEnviron([]string{
+				"APP__APP__SERVERS__1__PORT=3",
+				"APP__APP__SERVERS__2__HOST=c",
+			}),
+		)).To(BeNil())
+
+		var servers []struct {
+			Host string `yaml:"host"`
+			Port int    `yaml:"port"`
+		}
+		Expect(loader.Get(context.Background(), "app.servers", &servers)).To(BeNil())
+		Expect(servers).To(HaveLen(3))
+		Expect(servers[0].Host).To(Equal("a"))
+		Expect(servers[0].Port).To(Equal(1))
+		Expect(servers[1].Host).To(Equal("b"))
+		Expect(servers[1].Port).To(Equal(3))
+		Expect(servers[2].Host).To(Equal("c"))
+	})
+
+	It("should create sequences in order", func() {
+		loader := New()
+		Expect(loader.LoadEnv(EnvEnviron([]string{
+			"TAGS__2=k",
+			"TAGS__1=c",
+			"TAGS__0=a",
+		}))).To(BeNil())
+
+		var tags []string
+		Expect(loader.Get(context.Background(), "tags", &tags)).To(BeNil())
+		Expect(tags).To(Equal([]string{"a", "c", "k"}))
+
+		node, err := loader.GetNode(context.Background(), "tags[2]")
+		Expect(err).To(BeNil())
+		Expect(node.Keypath()).To(Equal("tags[2]"))
+	})
+
+	It("should add sequence items after the last one", func() {
+		loader := New()
+		Expect(loader.Load("app.yaml", []byte(`hosts: [a, b]`))).To(BeNil())
+		Expect(loader.LoadEnv(EnvEnviron([]string{"APP__HOSTS__3=d", "APP__HOSTS__2=c", "APP__HOSTS__0=z"}))).To(BeNil())
+
+		var hosts []string
+		Expect(loader.Get(context.Background(), "app.hosts", &hosts)).To(BeNil())
+		Expect(hosts).To(Equal([]string{"z", "b", "c", "d"}))
+	})
+
+	It("should not add sequence items past the end of the sequence", func() {
+		loader := New()
+		Expect(loader.Load("app.yaml", []byte(`hosts: [a, b]`))).To(BeNil())
+		err := loader.LoadEnv(EnvEnviron([]string{"APP__HOSTS__5=f"}))
+		Expect(err).To(MatchError(ContainSubstring(`"app.hosts": index 5 is past the end of the sequence, which has 2 items`)))
+	})
+
+	It("should use separator and key mapper", func() {
+		loader := New()
+		Expect(loader.Load("app.yaml", []byte(`listenPort: 80`))).To(BeNil())
+		Expect(loader.LoadEnv(
+			EnvPrefix("APP"),
+			EnvSeparator("_"),
+			EnvKeyMapper(func(s string) string {
+				return strings.ReplaceAll(strings.ToLower(s), "listenport", "listenPort")
+			}),
+			EnvEnviron([]string{"APP_APP_LISTENPORT=8080"}),
+		)).To(BeNil())
+
+		var port int
+		Expect(loader.Get(context.Background(), "app.listenPort", &port)).To(BeNil())
+		Expect(port).To(Equal(8080))
+	})
+
+	It("should report node errors with the variable name", func() {
+		loader := New().WithFeatures(FeatureFunc("!fail", func(ctx context.Context, loader *Loader, node *Node) (*Node, error) {
+			return nil, NewNodeError(node, ErrInvalidPath)
+		}))
+		Expect(loader.LoadEnv(EnvEnviron([]string{"APP__PORT=80"}))).To(BeNil())
+
+		node, err := loader.GetNode(context.Background(), "app.port")
+		Expect(err).To(BeNil())
+		Expect(NewNodeError(node, ErrInvalidPath).Error()).To(Equal("env:APP__PORT@0:0 invalid path"))
+	})
+})
+
+var _ = DescribeTable("Env failed scenarios", func(environ []string, message string) {
+	loader := New()
+	err := loader.LoadEnv(EnvEnviron(environ))
+	Expect(err).To(MatchError(ErrConfigParseError))
+	Expect(err.Error()).To(ContainSubstring(message))
+},
+	Entry("empty segment", []string{"A____B=1"}, "A____B: empty path segment"),
+	Entry("nested under value", []string{"A=1", "A__B=2"}, `A__B: "a" is already set`),
+	Entry("value over nested", []string{"A__B=2", "A__B__C=1"}, `A__B__C: "a.b" is already set`),
+	Entry("key of sequence", []string{"A__B=1", "A__0=2"}, `A__B: "a" is not a mapping`),
+	Entry("index at root", []string{"0=1"}, `0: "" is not a sequence`),
+	Entry("gap in sequence", []string{"A__0=1", "A__2=2"}, `"a": index 2 is past the end of the sequence, which has 1 items`),
+)
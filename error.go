@@ -4,6 +4,7 @@ import (
 	"fmt"
 )
 
+// newNodeError returns err at the position of node, with the sensitive values of node redacted from its message.
 func newNodeError(node *Node, err error) error {
-	return fmt.Errorf("%d:%d@%s: %w", node.line, node.column, node.Filepath(), err)
+	return fmt.Errorf("%d:%d@%s: %w", node.line, node.column, node.Source(), redactError(node, err))
 }
@@ -9,6 +9,9 @@ var (
 	ErrPathNotFound     = errors.New("path not found")
 	ErrConfigParseError = errors.New("config parse error")
 	ErrInvalidPath      = errors.New("invalid path")
+
+	ErrUnsupportedFormat = errors.New("unsupported format")
+	ErrCircularReference = errors.New("circular reference")
 )
 
 type nodeError struct {
@@ -16,8 +19,9 @@ type nodeError struct {
 	err  error
 }
 
+// Error redacts the sensitive values of the node from the message, Unwrap returns the error as it is.
 func (e *nodeError) Error() string {
-	return fmt.Sprintf("%s.yaml@%d:%d %s", e.node.Filepath(), e.node.Line(), e.node.Column(), e.err.Error())
+	return fmt.Sprintf("%s@%d:%d %s", e.node.Source(), e.node.Line(), e.node.Column(), redactError(e.node, e.err).Error())
 }
 
 func (e *nodeError) Unwrap() error {
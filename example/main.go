@@ -2,36 +2,27 @@ package main
 
 import (
 	"context"
-	_ "embed"
+	"embed"
 	"fmt"
-	"os"
+	"io/fs"
 
 	"github.com/joesonw/gofigure"
 	"github.com/joesonw/gofigure/feature"
 )
 
-//go:embed config/app.yaml
-var defaultYaml []byte
-
-//go:embed config/prod/app.yaml
-var envYaml []byte
-
-//go:embed config/storage/db.yaml
-var defaultDBYaml []byte
-
-//go:embed config/prod/storage/db.yaml
-var envDBYaml []byte
+//go:embed config
+var configFS embed.FS
 
 func main() {
+	configDir, err := fs.Sub(configFS, "config")
+	die(err)
+
 	loader := gofigure.New().WithFeatures(
 		feature.Reference(),
 		feature.Template(),
-		feature.Include(os.DirFS("./config")),
+		feature.Include(configDir),
 	)
-	die(loader.Load("app.yaml", defaultYaml))
-	die(loader.Load("storage/db.yaml", defaultDBYaml))
-	die(loader.Load("app.yaml", envYaml))
-	die(loader.Load("storage/db.yaml", envDBYaml))
+	die(loader.LoadFS(configDir, gofigure.FSProfiles("prod")))
 	var app struct {
 		Env      string `yaml:"env"`
 		Listen   string `yaml:"listen"`
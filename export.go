@@ -0,0 +1,189 @@
+package gofigure
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ExportFormat is a format the config can be exported to.
+type ExportFormat string
+
+const (
+	ExportYAML ExportFormat = "yaml"
+	ExportJSON ExportFormat = "json"
+	ExportTOML ExportFormat = "toml"
+	// ExportDotenv writes a KEY=value line per scalar, named the way LoadEnv reads them, e.g. STORAGE__DB__HOST.
+	ExportDotenv ExportFormat = "dotenv"
+	// ExportProperties writes a Java properties line per scalar, keyed by dot path, e.g. servers[0].host.
+	ExportProperties ExportFormat = "properties"
+)
+
+// Export writes the resolved config to w in format, keeping the order of the keys. Every value is resolved first, and
+// nothing is written if any fails to resolve. TOML can only export mappings and leaves out null values as it has none,
+// dotenv and properties cannot export a single scalar.
+func (l *Loader) Export(ctx context.Context, w io.Writer, format ExportFormat, options ...ExportOption) error {
+	o := defaultExportOptions()
+	for i := range options {
+		options[i].apply(o)
+	}
+
+	snapshot, err := l.current(ctx)
+	if err != nil {
+		return err
+	}
+	if _, err := snapshot.lookup(o.root); err != nil {
+		return err
+	}
+	raw, err := snapshot.lookupRaw(o.root)
+	if err != nil {
+		return err
+	}
+	if raw == nil {
+		return fmt.Errorf("%s: %w", o.root, ErrPathNotFound)
+	}
+
+	node := o.exportNode(raw, o.root, false)
+	if format == ExportTOML && node.kind != yaml.MappingNode {
+		return fmt.Errorf("unable to export %q as %s: not a mapping", o.root, format)
+	}
+	if (format == ExportDotenv || format == ExportProperties) && node.kind == yaml.ScalarNode {
+		return fmt.Errorf("unable to export %q as %s: not a mapping or a sequence", o.root, format)
+	}
+
+	switch format {
+	case ExportYAML:
+		encoder := yaml.NewEncoder(w)
+		if err := encoder.Encode(node.ToYAMLNode()); err != nil {
+			return err
+		}
+		return encoder.Close()
+	case ExportJSON:
+		return encodeJSON(w, node)
+	case ExportTOML:
+		return encodeTOML(w, node, o.provenance)
+	case ExportDotenv:
+		return encodeDotenv(w, node, o)
+	case ExportProperties:
+		return encodeProperties(w, node, o.provenance)
+	default:
+		return fmt.Errorf("%s: %w", format, ErrUnsupportedFormat)
+	}
+}
+
+// exportNode returns a copy of the resolved value of node at path, masked and commented according to the options.
+// Every node of the copy keeps the origins of the node it was copied from.
+func (o *exportOptions) exportNode(node *Node, path string, masked bool) *Node {
+	value := resolvedValue(node)
+	masked = masked || o.isMasked(path, value) || !o.reveal && node.Sensitive()
+
+	n := &Node{
+		kind:           value.kind,
+		style:          value.style &^ yaml.TaggedStyle,
+		headComment:    node.headComment,
+		lineComment:    node.lineComment,
+		footComment:    node.footComment,
+		keyHeadComment: node.keyHeadComment,
+		keyLineComment: node.keyLineComment,
+		keyFootComment: node.keyFootComment,
+		origins:        node.Origins(),
+	}
+	if strings.HasPrefix(value.tag, "!!") {
+		n.tag = value.tag
+	}
+
+	switch value.kind {
+	case yaml.MappingNode:
+		n.mappingNodes = make(map[string]*Node, len(value.mappingNodes))
+		for _, key := range value.MappingKeys() {
+			child := value.mappingNodes[key]
+			if child == nil {
+				continue
+			}
+			childPath := key
+			if path != "" {
+				childPath = path + "." + key
+			}
+			c := o.exportNode(child, childPath, masked)
+			c.parent = n
+			c.mappingKey = key
+			c.hasMappingKey = true
+			n.setMappingChild(key, c)
+		}
+	case yaml.SequenceNode:
+		n.sequenceNodes = make([]*Node, 0, len(value.sequenceNodes))
+		for i, child := range value.sequenceNodes {
+			if child == nil {
+				continue
+			}
+			c := o.exportNode(child, fmt.Sprintf("%s[%d]", path, i), masked)
+			c.parent = n
+			c.sequenceIndex = len(n.sequenceNodes)
+			c.hasSequenceIndex = true
+			n.sequenceNodes = append(n.sequenceNodes, c)
+		}
+	case yaml.ScalarNode:
+		n.value = value.value
+		if masked {
+			n.value = o.mask
+			n.tag = "!!str"
+			n.style = 0
+		}
+		if o.provenance {
+			n.lineComment = "# " + provenanceComment(n)
+		}
+	}
+	return n
+}
+
+func (o *exportOptions) isMasked(path string, value *Node) bool {
+	for _, pattern := range o.masks {
+		if MatchPath(pattern, path) {
+			return true
+		}
+	}
+	return o.maskFunc != nil && o.maskFunc(path, value)
+}
+
+// provenanceComment describes where the value of an exported node was set, for comments.
+func provenanceComment(n *Node) string {
+	origin := n.origins[len(n.origins)-1]
+	if origin.Tag != "" && !strings.HasPrefix(origin.Tag, "!!") {
+		return "from " + origin.Position() + " " + origin.Tag
+	}
+	return "from " + origin.Position()
+}
+
+// scalarValue returns the Go value of a scalar node, typed the way YAML types it.
+func scalarValue(n *Node) (any, error) {
+	var v any
+	if err := n.ToYAMLNode().Decode(&v); err != nil {
+		return nil, fmt.Errorf("%s: %w", n.Keypath(), err)
+	}
+	return v, nil
+}
+
+// eachScalar calls fn for every scalar under node in order, with the keys and indexes leading to it.
+func eachScalar(node *Node, segments []string, fn func(segments []string, node *Node) error) error {
+	switch node.kind {
+	case yaml.MappingNode:
+		for _, key := range node.MappingKeys() {
+			if err := eachScalar(node.mappingNodes[key], append(segments[:len(segments):len(segments)], key), fn); err != nil {
+				return err
+			}
+		}
+	case yaml.SequenceNode:
+		for i, child := range node.sequenceNodes {
+			if err := eachScalar(child, append(segments[:len(segments):len(segments)], strconv.Itoa(i)), fn); err != nil {
+				return err
+			}
+		}
+	case yaml.ScalarNode:
+		return fn(segments, node)
+	}
+	return nil
+}
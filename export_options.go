@@ -0,0 +1,88 @@
+package gofigure
+
+type exportOptions struct {
+	root         string
+	provenance   bool
+	masks        []string
+	maskFunc     func(path string, node *Node) bool
+	mask         string
+	reveal       bool
+	envPrefix    string
+	envSeparator string
+}
+
+func defaultExportOptions() *exportOptions {
+	return &exportOptions{
+		mask:         Redacted,
+		envSeparator: "__",
+	}
+}
+
+type ExportOption interface {
+	apply(*exportOptions)
+}
+
+type exportOptionFunc func(*exportOptions)
+
+func (f exportOptionFunc) apply(o *exportOptions) {
+	f(o)
+}
+
+// ExportRoot only exports the subtree at path, the whole config by default. Keys are written relative to it.
+func ExportRoot(path string) ExportOption {
+	return exportOptionFunc(func(o *exportOptions) {
+		o.root = path
+	})
+}
+
+// ExportProvenance writes where every value was set as a comment next to it, in every format but JSON.
+func ExportProvenance() ExportOption {
+	return exportOptionFunc(func(o *exportOptions) {
+		o.provenance = true
+	})
+}
+
+// ExportMask masks the values at the paths matching any of patterns, and every value under them. Patterns are dot
+// paths in which "*" matches any single key or index and "**" any number of them, e.g. "**.password".
+func ExportMask(patterns ...string) ExportOption {
+	return exportOptionFunc(func(o *exportOptions) {
+		o.masks = append(o.masks, patterns...)
+	})
+}
+
+// ExportMaskFunc masks the values for which fn returns true, along with every value under them. fn is called with the
+// full dot path and the resolved value of every node.
+func ExportMaskFunc(fn func(path string, node *Node) bool) ExportOption {
+	return exportOptionFunc(func(o *exportOptions) {
+		o.maskFunc = fn
+	})
+}
+
+// ExportMaskValue sets the string masked values are replaced with, "******" by default.
+func ExportMaskValue(mask string) ExportOption {
+	return exportOptionFunc(func(o *exportOptions) {
+		o.mask = mask
+	})
+}
+
+// ExportEnvPrefix prefixes the variable names of the dotenv format with prefix followed by the separator, the same way
+// EnvPrefix reads them.
+func ExportEnvPrefix(prefix string) ExportOption {
+	return exportOptionFunc(func(o *exportOptions) {
+		o.envPrefix = prefix
+	})
+}
+
+// ExportEnvSeparator sets the separator between path segments of the dotenv format, "__" by default.
+func ExportEnvSeparator(separator string) ExportOption {
+	return exportOptionFunc(func(o *exportOptions) {
+		o.envSeparator = separator
+	})
+}
+
+// ExportRevealSensitive exports sensitive values as they are, see Node.Sensitive. They are masked by default.
+func ExportRevealSensitive() ExportOption {
+	return exportOptionFunc(func(o *exportOptions) {
+		o.reveal = true
+	})
+}
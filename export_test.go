@@ -0,0 +1,156 @@
+package gofigure
+
+import (
+	"bytes"
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Export", func() {
+	var loader *Loader
+
+	BeforeEach(func() {
+		loader = New().WithFeatures(refFeature)
+		Expect(loader.Load("app.yaml", []byte(`# the name
+name: gofigure
+port: 8080
+debug: false
+ratio: 1.5
+empty:
+db:
+  host: localhost
+  password: hunter2
+listen: !ref app.port
+tags: [a, "b c"]
+servers:
+  - host: a
+    port: 1
+  - host: b
+    port: 2
+`))).To(BeNil())
+		Expect(loader.Load("app.json", []byte(`{"port": 80}`))).To(BeNil())
+	})
+
+	export := func(format ExportFormat, options ...ExportOption) string {
+		var buf bytes.Buffer
+		Expect(loader.Export(context.Background(), &buf, format, options...)).To(BeNil())
+		return buf.String()
+	}
+
+	It("should export yaml", func() {
+		Expect(export(ExportYAML, ExportRoot("app"), ExportMask("app.db.password"))).To(Equal(`# the name
+name: gofigure
+port: 80
+debug: false
+ratio: 1.5
+empty:
+db:
+    host: localhost
+    password: '******'
+listen: 80
+tags: [a, "b c"]
+servers:
+    - host: a
+      port: 1
+    - host: b
+      port: 2
+`))
+	})
+
+	It("should export json", func() {
+		Expect(export(ExportJSON, ExportRoot("app"), ExportMask("**.password"))).To(Equal(`{
+  "name": "gofigure",
+  "port": 80,
+  "debug": false,
+  "ratio": 1.5,
+  "empty": null,
+  "db": {
+    "host": "localhost",
+    "password": "******"
+  },
+  "listen": 80,
+  "tags": [
+    "a",
+    "b c"
+  ],
+  "servers": [
+    {
+      "host": "a",
+      "port": 1
+    },
+    {
+      "host": "b",
+      "port": 2
+    }
+  ]
+}
+`))
+	})
+
+	It("should export toml", func() {
+		Expect(export(ExportTOML, ExportRoot("app"), ExportMask("app.db"))).To(Equal(`name = "gofigure"
+port = 80
+debug = false
+ratio = 1.5
+listen = 80
+tags = ["a", "b c"]
+
+[db]
+host = "******"
+password = "******"
+
+[[servers]]
+host = "a"
+port = 1
+
+[[servers]]
+host = "b"
+port = 2
+`))
+	})
+
+	It("should export dotenv", func() {
+		Expect(export(ExportDotenv, ExportRoot("app.servers"), ExportEnvPrefix("app"))).To(Equal(`APP__0__HOST=a
+APP__0__PORT=1
+APP__1__HOST=b
+APP__1__PORT=2
+`))
+		Expect(export(ExportDotenv, ExportRoot("app.tags"))).To(Equal("0=a\n1=\"b c\"\n"))
+	})
+
+	It("should export properties", func() {
+		Expect(export(ExportProperties, ExportRoot("app.db"), ExportMaskFunc(func(path string, node *Node) bool {
+			return node.Value() == "hunter2"
+		}))).To(Equal("host=localhost\npassword=******\n"))
+	})
+
+	It("should write provenance comments", func() {
+		Expect(export(ExportYAML, ExportRoot("app.db"), ExportProvenance())).To(Equal(`host: localhost # from app.yaml:8:9
+password: hunter2 # from app.yaml:9:13
+`))
+		Expect(export(ExportTOML, ExportRoot("app"), ExportProvenance(), ExportMask("app.servers", "app.tags", "app.db"))).
+			To(ContainSubstring("port = 80 # from app.json:1:10\n"))
+		Expect(export(ExportProperties, ExportRoot("app"), ExportProvenance())).
+			To(ContainSubstring("# from app.yaml:10:9 !ref\nlisten=80\n"))
+	})
+
+	It("should not export what cannot be exported", func() {
+		var buf bytes.Buffer
+		err := loader.Export(context.Background(), &buf, ExportYAML, ExportRoot("app.missing"))
+		Expect(errors.Is(err, ErrPathNotFound)).To(BeTrue())
+
+		err = loader.Export(context.Background(), &buf, ExportTOML, ExportRoot("app.port"))
+		Expect(err).To(MatchError(`unable to export "app.port" as toml: not a mapping`))
+
+		err = loader.Export(context.Background(), &buf, "xml")
+		Expect(errors.Is(err, ErrUnsupportedFormat)).To(BeTrue())
+
+		Expect(loader.Load("broken.yaml", []byte(`value: !ref missing`))).To(BeNil())
+		err = loader.Export(context.Background(), &buf, ExportYAML)
+		Expect(errors.Is(err, ErrPathNotFound)).To(BeTrue())
+		Expect(buf.Len()).To(BeZero())
+	})
+})
@@ -9,6 +9,20 @@ type Feature interface {
 	Resolve(ctx context.Context, loader *Loader, node *Node) (*Node, error)
 }
 
+// CacheableFeature is a Feature that tells whether its results can be reused by the next snapshot. The results of
+// features that do not implement it are never reused, as they may read anything, e.g. files or environment variables.
+type CacheableFeature interface {
+	Feature
+	// Cacheable reports whether the results only depend on the node and the values the feature gets from the loader.
+	Cacheable() bool
+}
+
+// cacheable reports whether the results of feature can be reused.
+func cacheable(feature Feature) bool {
+	c, ok := feature.(CacheableFeature)
+	return ok && c.Cacheable()
+}
+
 type ResolveFunc func(ctx context.Context, loader *Loader, node *Node) (*Node, error)
 
 type featureFunc struct {
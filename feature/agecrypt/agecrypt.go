@@ -0,0 +1,51 @@
+// Package agecrypt encrypts values for the !decrypt feature with age (https://age-encryption.org), and decrypts them,
+// apart from the feature package so that only the programs using age depend on it.
+package agecrypt
+
+import (
+	"bytes"
+	"io"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+
+	"github.com/joesonw/gofigure/feature"
+)
+
+type identities []age.Identity
+
+// Identities decrypts the age ciphertexts of !decrypt with identities, e.g. from age.ParseIdentities:
+//
+//	feature.Decrypt().Age(agecrypt.Identities(identities...))
+func Identities(ids ...age.Identity) feature.AgeDecrypter {
+	return identities(ids)
+}
+
+func (ids identities) DecryptAge(ciphertext string) ([]byte, error) {
+	r, err := age.Decrypt(armor.NewReader(strings.NewReader(ciphertext)), ids...)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
+
+// Encrypt returns the armored age ciphertext of plaintext for !decrypt, which any of recipients can decrypt.
+func Encrypt(plaintext []byte, recipients ...age.Recipient) (string, error) {
+	var b bytes.Buffer
+	armored := armor.NewWriter(&b)
+	w, err := age.Encrypt(armored, recipients...)
+	if err != nil {
+		return "", err
+	}
+	if _, err := w.Write(plaintext); err != nil {
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+	if err := armored.Close(); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
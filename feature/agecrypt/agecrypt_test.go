@@ -0,0 +1,49 @@
+package agecrypt_test
+
+import (
+	"context"
+	"strings"
+
+	"filippo.io/age"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/joesonw/gofigure"
+	"github.com/joesonw/gofigure/feature"
+	"github.com/joesonw/gofigure/feature/agecrypt"
+)
+
+var _ = Describe("agecrypt", func() {
+	indent := func(s string) string {
+		return "  " + strings.ReplaceAll(strings.TrimSpace(s), "\n", "\n  ")
+	}
+
+	It("should decrypt age ciphertexts", func() {
+		identity, err := age.GenerateX25519Identity()
+		Expect(err).To(BeNil())
+		other, err := age.GenerateX25519Identity()
+		Expect(err).To(BeNil())
+		ciphertext, err := agecrypt.Encrypt([]byte("hunter2"), other.Recipient(), identity.Recipient())
+		Expect(err).To(BeNil())
+		Expect(ciphertext).To(HavePrefix("-----BEGIN AGE ENCRYPTED FILE-----"))
+
+		loader := gofigure.New().WithFeatures(feature.Decrypt().Age(agecrypt.Identities(identity)))
+		Expect(loader.Load("app.yaml", []byte("password: !decrypt |\n"+indent(ciphertext)))).To(BeNil())
+		Expect(gofigure.Get[string](context.Background(), loader, "app.password")).To(Equal("hunter2"))
+	})
+
+	It("should fail on ciphertexts it cannot decrypt", func() {
+		identity, err := age.GenerateX25519Identity()
+		Expect(err).To(BeNil())
+		other, err := age.GenerateX25519Identity()
+		Expect(err).To(BeNil())
+		ciphertext, err := agecrypt.Encrypt([]byte("hunter2"), identity.Recipient())
+		Expect(err).To(BeNil())
+
+		_, err = feature.Decrypt().DecryptValue(context.Background(), ciphertext)
+		Expect(err).To(MatchError("unable to decrypt: no age identity"))
+
+		_, err = feature.Decrypt().Age(agecrypt.Identities(other)).DecryptValue(context.Background(), ciphertext)
+		Expect(err).To(MatchError(ContainSubstring("unable to decrypt: no identity matched any of the recipients")))
+	})
+})
@@ -0,0 +1,196 @@
+package feature
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	iofs "io/fs"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/joesonw/gofigure"
+)
+
+// aesPrefix starts AES-GCM ciphertexts, "aes-gcm:<key id>:<base64 of the nonce followed by the sealed value>".
+const aesPrefix = "aes-gcm:"
+
+// ageHeader starts armored age ciphertexts.
+const ageHeader = "-----BEGIN AGE ENCRYPTED FILE-----"
+
+// ErrKeyNotFound is returned by a Keyring that has no key of an id.
+var ErrKeyNotFound = errors.New("key not found")
+
+// Keyring provides the AES keys !decrypt decrypts with, by id.
+type Keyring interface {
+	// Key returns the AES key of id, 16, 24 or 32 bytes long, or ErrKeyNotFound.
+	Key(ctx context.Context, id string) ([]byte, error)
+}
+
+// KeyringFunc is a Keyring calling a function.
+type KeyringFunc func(ctx context.Context, id string) ([]byte, error)
+
+func (f KeyringFunc) Key(ctx context.Context, id string) ([]byte, error) {
+	return f(ctx, id)
+}
+
+// MapKeyring is a Keyring of the keys in keys.
+func MapKeyring(keys map[string][]byte) Keyring {
+	return KeyringFunc(func(_ context.Context, id string) ([]byte, error) {
+		key, ok := keys[id]
+		if !ok {
+			return nil, ErrKeyNotFound
+		}
+		return key, nil
+	})
+}
+
+// FileKeyring is a Keyring reading the key of id, encoded in base64, from the file of that name in fs.
+func FileKeyring(fs iofs.FS) Keyring {
+	return KeyringFunc(func(_ context.Context, id string) ([]byte, error) {
+		if !iofs.ValidPath(id) {
+			return nil, fmt.Errorf("invalid key id %q", id)
+		}
+		contents, err := iofs.ReadFile(fs, id)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrKeyNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(contents)))
+		if err != nil {
+			return nil, fmt.Errorf("invalid key %q: %w", id, err)
+		}
+		return key, nil
+	})
+}
+
+var _ gofigure.CacheableFeature = (*DecryptFeature)(nil)
+
+// DecryptFeature resolves !decrypt nodes to the plaintext of the ciphertext they hold, which is either an AES-GCM
+// ciphertext of a key of the keyring, see EncryptAES, or an armored age ciphertext, see agecrypt.Encrypt. The
+// plaintext is typed as a plain scalar, and its node is marked as sensitive.
+type DecryptFeature struct {
+	keyring Keyring
+	age     AgeDecrypter
+}
+
+// AgeDecrypter decrypts armored age ciphertexts, see the agecrypt package, which keeps age out of this one.
+type AgeDecrypter interface {
+	DecryptAge(ciphertext string) ([]byte, error)
+}
+
+func Decrypt() *DecryptFeature {
+	return &DecryptFeature{}
+}
+
+// Keyring sets the keyring AES-GCM ciphertexts are decrypted with.
+func (f *DecryptFeature) Keyring(keyring Keyring) *DecryptFeature {
+	f.keyring = keyring
+	return f
+}
+
+// Age sets how age ciphertexts are decrypted, e.g. agecrypt.Identities.
+func (f *DecryptFeature) Age(decrypter AgeDecrypter) *DecryptFeature {
+	f.age = decrypter
+	return f
+}
+
+func (*DecryptFeature) Name() string {
+	return "!decrypt"
+}
+
+// Cacheable reports true, as the plaintext only depends on the ciphertext and the key it is decrypted with.
+func (*DecryptFeature) Cacheable() bool {
+	return true
+}
+
+func (f *DecryptFeature) Resolve(ctx context.Context, _ *gofigure.Loader, node *gofigure.Node) (*gofigure.Node, error) {
+	if node.Kind() != yaml.ScalarNode {
+		return nil, fmt.Errorf("!decrypt only supports scalar node")
+	}
+
+	plaintext, err := f.DecryptValue(ctx, node.Value())
+	if err != nil {
+		return nil, gofigure.NewNodeError(node, err)
+	}
+	return gofigure.NewNode(&yaml.Node{Kind: yaml.ScalarNode, Value: string(plaintext)}, gofigure.NodeSensitive()), nil
+}
+
+// DecryptValue decrypts a ciphertext the way !decrypt does.
+func (f *DecryptFeature) DecryptValue(ctx context.Context, ciphertext string) ([]byte, error) {
+	ciphertext = strings.TrimSpace(ciphertext)
+	switch {
+	case strings.HasPrefix(ciphertext, aesPrefix):
+		if f.keyring == nil {
+			return nil, fmt.Errorf("unable to decrypt: no keyring")
+		}
+		id, sealed, ok := strings.Cut(strings.TrimPrefix(ciphertext, aesPrefix), ":")
+		if !ok {
+			return nil, fmt.Errorf("unable to decrypt: invalid AES-GCM ciphertext")
+		}
+		key, err := f.keyring.Key(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get key %q: %w", id, err)
+		}
+		return decryptAES(key, id, sealed)
+	case strings.HasPrefix(ciphertext, ageHeader):
+		if f.age == nil {
+			return nil, fmt.Errorf("unable to decrypt: no age identity")
+		}
+		plaintext, err := f.age.DecryptAge(ciphertext)
+		if err != nil {
+			return nil, fmt.Errorf("unable to decrypt: %w", err)
+		}
+		return plaintext, nil
+	}
+	return nil, fmt.Errorf("unable to decrypt: expected an %s ciphertext or an armored age ciphertext", strings.TrimSuffix(aesPrefix, ":"))
+}
+
+func decryptAES(key []byte, id, sealed string) ([]byte, error) {
+	aead, err := newAESGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil || len(data) < aead.NonceSize() {
+		return nil, fmt.Errorf("unable to decrypt: invalid AES-GCM ciphertext")
+	}
+	plaintext, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], []byte(id))
+	if err != nil {
+		return nil, fmt.Errorf("unable to decrypt with key %q: %w", id, err)
+	}
+	return plaintext, nil
+}
+
+func newAESGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid AES key: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// EncryptAES returns the AES-GCM ciphertext of plaintext for !decrypt, with the key of id, which is authenticated
+// along with it.
+func EncryptAES(key []byte, id string, plaintext []byte) (string, error) {
+	if id == "" || strings.Contains(id, ":") {
+		return "", fmt.Errorf("invalid key id %q", id)
+	}
+	aead, err := newAESGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, plaintext, []byte(id))
+	return aesPrefix + id + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
@@ -0,0 +1,95 @@
+package feature_test
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/psanford/memfs"
+
+	"github.com/joesonw/gofigure"
+	"github.com/joesonw/gofigure/feature"
+)
+
+var _ = Describe("!decrypt", func() {
+	key := []byte("0123456789abcdef0123456789abcdef")
+	keyring := feature.MapKeyring(map[string][]byte{"prod": key})
+
+	It("should decrypt AES-GCM ciphertexts", func() {
+		ciphertext, err := feature.EncryptAES(key, "prod", []byte("hunter2"))
+		Expect(err).To(BeNil())
+		Expect(ciphertext).To(HavePrefix("aes-gcm:prod:"))
+
+		port, err := feature.EncryptAES(key, "prod", []byte("5432"))
+		Expect(err).To(BeNil())
+
+		loader := gofigure.New().WithFeatures(feature.Decrypt().Keyring(keyring))
+		Expect(loader.Load("app.yaml", []byte("password: !decrypt "+ciphertext+"\nport: !decrypt "+port))).To(BeNil())
+		Expect(gofigure.Get[string](context.Background(), loader, "app.password")).To(Equal("hunter2"))
+		Expect(gofigure.Get[int](context.Background(), loader, "app.port")).To(Equal(5432))
+
+		node, err := loader.GetNode(context.Background(), "app.password")
+		Expect(err).To(BeNil())
+		Expect(node.Sensitive()).To(BeTrue())
+	})
+
+	It("should not decrypt the same ciphertexts again on reload", func() {
+		ciphertext, err := feature.EncryptAES(key, "prod", []byte("hunter2"))
+		Expect(err).To(BeNil())
+		var decrypted int
+		counting := feature.KeyringFunc(func(ctx context.Context, id string) ([]byte, error) {
+			decrypted++
+			return keyring.Key(ctx, id)
+		})
+
+		fs := memfs.New()
+		Expect(fs.WriteFile("app.yaml", []byte("password: !decrypt "+ciphertext+"\nport: 80"), 0644)).To(BeNil())
+		loader := gofigure.New().WithFeatures(feature.Decrypt().Keyring(counting))
+		Expect(loader.LoadFS(fs)).To(BeNil())
+		Expect(gofigure.Get[string](context.Background(), loader, "app.password")).To(Equal("hunter2"))
+		Expect(decrypted).To(Equal(1))
+
+		Expect(fs.WriteFile("app.yaml", []byte("password: !decrypt "+ciphertext+"\nport: 8080"), 0644)).To(BeNil())
+		changed, err := loader.Reload(context.Background())
+		Expect(err).To(BeNil())
+		Expect(changed).To(Equal([]string{"app.port"}))
+		Expect(gofigure.Get[string](context.Background(), loader, "app.password")).To(Equal("hunter2"))
+		Expect(decrypted).To(Equal(1))
+	})
+
+	It("should read keys from files", func() {
+		fs := memfs.New()
+		Expect(fs.WriteFile("prod", []byte(base64.StdEncoding.EncodeToString(key)+"\n"), 0600)).To(BeNil())
+		ciphertext, err := feature.EncryptAES(key, "prod", []byte("hunter2"))
+		Expect(err).To(BeNil())
+
+		plaintext, err := feature.Decrypt().Keyring(feature.FileKeyring(fs)).DecryptValue(context.Background(), ciphertext)
+		Expect(err).To(BeNil())
+		Expect(string(plaintext)).To(Equal("hunter2"))
+
+		_, err = feature.FileKeyring(fs).Key(context.Background(), "staging")
+		Expect(errors.Is(err, feature.ErrKeyNotFound)).To(BeTrue())
+	})
+
+	It("should fail on ciphertexts it cannot decrypt", func() {
+		ciphertext, err := feature.EncryptAES(key, "prod", []byte("hunter2"))
+		Expect(err).To(BeNil())
+		tampered := strings.Replace(ciphertext, "aes-gcm:prod:", "aes-gcm:staging:", 1)
+
+		decrypt := feature.Decrypt().Keyring(feature.MapKeyring(map[string][]byte{"prod": key, "staging": key}))
+		_, err = decrypt.DecryptValue(context.Background(), tampered)
+		Expect(err).To(MatchError(ContainSubstring(`unable to decrypt with key "staging"`)))
+
+		_, err = decrypt.DecryptValue(context.Background(), "aes-gcm:dev:AAAA")
+		Expect(errors.Is(err, feature.ErrKeyNotFound)).To(BeTrue())
+
+		_, err = decrypt.DecryptValue(context.Background(), "hunter2")
+		Expect(err).To(MatchError("unable to decrypt: expected an aes-gcm ciphertext or an armored age ciphertext"))
+
+		_, err = feature.EncryptAES([]byte("short"), "prod", []byte("hunter2"))
+		Expect(err).To(MatchError(ContainSubstring("invalid AES key")))
+	})
+})
@@ -0,0 +1,153 @@
+package feature
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/joesonw/gofigure"
+)
+
+var _ gofigure.Feature = (*EnvFeature)(nil)
+
+// EnvFeature resolves !env nodes to the value of an environment variable, either `!env NAME`, or a mapping of
+// name, default, required and type, e.g.
+//
+//	port: !env
+//	  name: PORT
+//	  default: 8080
+//	  type: int
+//
+// A variable that is not set resolves to its default, to null if it has none, or fails if it is required. type is
+// one of string, int, float or bool, the value is typed the way YAML types a plain scalar if it is omitted.
+type EnvFeature struct {
+	lookup func(name string) (string, bool)
+}
+
+func Env() *EnvFeature {
+	return &EnvFeature{
+		lookup: os.LookupEnv,
+	}
+}
+
+// Lookup replaces os.LookupEnv to read variables, e.g. from a map in tests.
+func (f *EnvFeature) Lookup(lookup func(name string) (string, bool)) *EnvFeature {
+	f.lookup = lookup
+	return f
+}
+
+func (*EnvFeature) Name() string {
+	return "!env"
+}
+
+//nolint:gocyclo
+func (f *EnvFeature) Resolve(_ context.Context, _ *gofigure.Loader, node *gofigure.Node) (*gofigure.Node, error) {
+	var name, typ string
+	var defaultNode *gofigure.Node
+	required := false
+
+	switch node.Kind() {
+	case yaml.ScalarNode:
+		name = strings.TrimSpace(node.Value())
+	case yaml.MappingNode:
+		for _, key := range node.MappingKeys() {
+			child, err := node.GetMappingChild(key)
+			if err != nil {
+				return nil, gofigure.NewNodeError(node, err)
+			}
+			if child.Kind() != yaml.ScalarNode {
+				return nil, gofigure.NewNodeError(child, fmt.Errorf("key %q must be a scalar", key))
+			}
+
+			switch key {
+			case "name":
+				name = strings.TrimSpace(child.Value())
+			case "default":
+				defaultNode = child
+			case "required":
+				if required, err = child.BoolValue(); err != nil {
+					return nil, gofigure.NewNodeError(child, err)
+				}
+			case "type":
+				typ = strings.TrimSpace(child.Value())
+			default:
+				return nil, gofigure.NewNodeError(child, fmt.Errorf("unknown key %q, expected name, default, required or type", key))
+			}
+		}
+	default:
+		return nil, fmt.Errorf("!env only supports scalar and mapping nodes")
+	}
+
+	if name == "" {
+		return nil, gofigure.NewNodeError(node, fmt.Errorf("!env requires the name of a variable"))
+	}
+	switch typ {
+	case "", "string", "int", "float", "bool":
+	default:
+		return nil, gofigure.NewNodeError(node, fmt.Errorf("unknown type %q, expected string, int, float or bool", typ))
+	}
+
+	value, ok := f.lookup(name)
+	source, label := "env:"+name, "env:"+name
+	if !ok {
+		switch {
+		case required:
+			return nil, gofigure.NewNodeError(node, fmt.Errorf("environment variable %s is not set", name))
+		case defaultNode != nil:
+			value, source, label = defaultNode.Value(), defaultNode.Source(), "default"
+		default:
+			return gofigure.NewNode(&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null"}, gofigure.NodeSource(source)), nil
+		}
+	}
+
+	typed, err := typedScalar(value, typ)
+	if err != nil {
+		return nil, gofigure.NewNodeError(node, fmt.Errorf("%s: %w", label, err))
+	}
+	return gofigure.NewNode(typed, gofigure.NodeSource(source)), nil
+}
+
+// typedScalar returns value as a scalar of type typ, one of string, int, float or bool, or as a plain scalar if typ
+// is empty.
+func typedScalar(value, typ string) (*yaml.Node, error) {
+	switch typ {
+	case "":
+		return &yaml.Node{Kind: yaml.ScalarNode, Value: value}, nil
+	case "string":
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}, nil
+	case "int":
+		i, err := strconv.ParseInt(strings.ReplaceAll(strings.TrimSpace(value), "_", ""), 0, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not an int", value)
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.FormatInt(i, 10)}, nil
+	case "float":
+		f, err := strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(value), "_", ""), 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a float", value)
+		}
+		formatted := strconv.FormatFloat(f, 'g', -1, 64)
+		switch {
+		case math.IsInf(f, 1):
+			formatted = ".inf"
+		case math.IsInf(f, -1):
+			formatted = "-.inf"
+		case math.IsNaN(f):
+			formatted = ".nan"
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!float", Value: formatted}, nil
+	case "bool":
+		switch strings.ToLower(strings.TrimSpace(value)) {
+		case "true", "yes", "on", "1":
+			return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: "true"}, nil
+		case "false", "no", "off", "0":
+			return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: "false"}, nil
+		}
+	}
+	return nil, fmt.Errorf("%q is not a %s", value, typ)
+}
@@ -0,0 +1,125 @@
+package feature_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/joesonw/gofigure"
+	"github.com/joesonw/gofigure/feature"
+)
+
+var _ = Describe("!env", func() {
+	environ := map[string]string{
+		"HOST":    "db.local",
+		"PORT":    "5432",
+		"DEBUG":   "yes",
+		"RATIO":   "0.25",
+		"VERSION": "1.10",
+		"EMPTY":   "",
+	}
+	lookup := func(name string) (string, bool) {
+		value, ok := environ[name]
+		return value, ok
+	}
+
+	load := func(content string) *gofigure.Loader {
+		loader := gofigure.New().WithFeatures(feature.Env().Lookup(lookup))
+		Expect(loader.Load("app.yaml", []byte(content))).To(BeNil())
+		return loader
+	}
+
+	It("should resolve variables by name", func() {
+		loader := load(`host: !env HOST
+port: !env PORT
+empty: !env EMPTY
+missing: !env MISSING`)
+		Expect(gofigure.Get[string](context.Background(), loader, "app.host")).To(Equal("db.local"))
+		Expect(gofigure.Get[int](context.Background(), loader, "app.port")).To(Equal(5432))
+		Expect(gofigure.Get[string](context.Background(), loader, "app.empty")).To(Equal(""))
+
+		node, err := loader.GetNode(context.Background(), "app.missing")
+		Expect(err).To(BeNil())
+		Expect(node.Tag()).To(Equal("!!null"))
+		Expect(node.Source()).To(Equal("env:MISSING"))
+	})
+
+	It("should read variables again for every snapshot", func() {
+		loader := load(`host: !env HOST`)
+		Expect(gofigure.Get[string](context.Background(), loader, "app.host")).To(Equal("db.local"))
+
+		environ["HOST"] = "db.remote"
+		defer func() { environ["HOST"] = "db.local" }()
+		Expect(loader.Load("storage/db.yaml", []byte(`port: 3306`))).To(BeNil())
+		Expect(gofigure.Get[string](context.Background(), loader, "app.host")).To(Equal("db.remote"))
+	})
+
+	It("should type values", func() {
+		loader := load(`port: !env {name: PORT, type: int}
+debug: !env {name: DEBUG, type: bool}
+ratio: !env {name: RATIO, type: float}
+version: !env {name: VERSION, type: string}
+plain: !env VERSION`)
+		var config struct {
+			Port    any `yaml:"port"`
+			Debug   any `yaml:"debug"`
+			Ratio   any `yaml:"ratio"`
+			Version any `yaml:"version"`
+			Plain   any `yaml:"plain"`
+		}
+		Expect(loader.Get(context.Background(), "app", &config)).To(BeNil())
+		Expect(config.Port).To(Equal(5432))
+		Expect(config.Debug).To(Equal(true))
+		Expect(config.Ratio).To(Equal(0.25))
+		Expect(config.Version).To(Equal("1.10"))
+		Expect(config.Plain).To(Equal(1.1))
+	})
+
+	It("should fall back to defaults", func() {
+		loader := load(`port: !env
+  name: MISSING
+  default: 8080
+  type: int
+host: !env {name: HOST, default: localhost}`)
+		Expect(gofigure.Get[int](context.Background(), loader, "app.port")).To(Equal(8080))
+		Expect(gofigure.Get[string](context.Background(), loader, "app.host")).To(Equal("db.local"))
+
+		explanation, err := loader.Explain(context.Background(), "app.port")
+		Expect(err).To(BeNil())
+		Expect(explanation.String()).To(ContainSubstring("!env"))
+	})
+
+	It("should fail on missing required variables", func() {
+		loader := load(`host: !env {name: HOST, required: true}
+password: !env
+  name: PASSWORD
+  required: true`)
+		Expect(gofigure.Get[string](context.Background(), loader, "app.host")).To(Equal("db.local"))
+		_, err := gofigure.Get[string](context.Background(), loader, "app.password")
+		Expect(err).NotTo(BeNil())
+		Expect(err.Error()).To(ContainSubstring("app.yaml@2:11 environment variable PASSWORD is not set"))
+	})
+
+	It("should fail on values of the wrong type", func() {
+		loader := load(`port: !env {name: HOST, type: int}
+debug: !env {name: MISSING, default: maybe, type: bool}
+ratio: !env {name: RATIO, type: decimal}
+name: !env {value: HOST}`)
+		_, err := gofigure.Get[int](context.Background(), loader, "app.port")
+		Expect(err).NotTo(BeNil())
+		Expect(err.Error()).To(ContainSubstring(`app.yaml@1:7 env:HOST: "db.local" is not an int`))
+
+		_, err = gofigure.Get[bool](context.Background(), loader, "app.debug")
+		Expect(err).NotTo(BeNil())
+		Expect(err.Error()).To(ContainSubstring(`app.yaml@2:8 default: "maybe" is not a bool`))
+
+		_, err = gofigure.Get[float64](context.Background(), loader, "app.ratio")
+		Expect(err).NotTo(BeNil())
+		Expect(err.Error()).To(ContainSubstring(`unknown type "decimal", expected string, int, float or bool`))
+
+		_, err = gofigure.Get[string](context.Background(), loader, "app.name")
+		Expect(err).NotTo(BeNil())
+		Expect(err.Error()).To(ContainSubstring(`unknown key "value", expected name, default, required or type`))
+	})
+})
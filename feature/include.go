@@ -15,16 +15,12 @@ import (
 )
 
 type includeFeature struct {
-	fs             []iofs.FS
-	loadedContents map[string][]byte
-	loadedNodes    map[string]bool
+	fs []iofs.FS
 }
 
 func Include(fs ...iofs.FS) gofigure.Feature {
 	return &includeFeature{
-		fs:             fs,
-		loadedContents: map[string][]byte{},
-		loadedNodes:    map[string]bool{},
+		fs: fs,
 	}
 }
 
@@ -62,6 +58,11 @@ func (f *includeFeature) Resolve(ctx context.Context, loader *gofigure.Loader, n
 			return nil, gofigure.NewNodeError(fileNode, fmt.Errorf("unable to get key: %w", err))
 		}
 
+		formatNode, err := fileNode.GetMappingChild("format")
+		if err != nil {
+			return nil, gofigure.NewNodeError(fileNode, fmt.Errorf("unable to get format: %w", err))
+		}
+
 		parse := false
 		if parseNode != nil {
 			parse, err = parseNode.BoolValue()
@@ -71,35 +72,37 @@ func (f *includeFeature) Resolve(ctx context.Context, loader *gofigure.Loader, n
 		}
 
 		path := strings.TrimSpace(pathNode.Value())
-		contents, ok := f.loadedContents[path]
-		if !ok {
-			found := false
-			for _, fs := range f.fs {
-				contents, err = iofs.ReadFile(fs, path)
-				if errors.Is(err, os.ErrNotExist) {
-					continue
-				}
-				if err != nil {
-					return nil, gofigure.NewNodeError(pathNode, fmt.Errorf("unable to read file %q: %w", path, err))
-				}
-				found = true
-				f.loadedContents[path] = contents
-				break
+		// the file is read again every time the config is resolved, so changes are picked up on reload
+		var contents []byte
+		found := false
+		for _, fs := range f.fs {
+			contents, err = iofs.ReadFile(fs, path)
+			if errors.Is(err, os.ErrNotExist) {
+				continue
 			}
-			if !found {
-				return nil, gofigure.NewNodeError(pathNode, fmt.Errorf("unable to find file %q: %w", path, os.ErrNotExist))
+			if err != nil {
+				return nil, gofigure.NewNodeError(pathNode, fmt.Errorf("unable to read file %q: %w", path, err))
 			}
+			found = true
+			break
+		}
+		if !found {
+			return nil, gofigure.NewNodeError(pathNode, fmt.Errorf("unable to find file %q: %w", path, os.ErrNotExist))
 		}
 
 		if !parse {
 			return gofigure.NewScalarNode(string(contents)), nil
 		}
 
-		if !f.loadedNodes[path] {
-			if err := loader.Load(path, f.loadedContents[path]); err != nil {
-				return nil, gofigure.NewNodeError(pathNode, fmt.Errorf("unable to load file %q: %w", path, err))
-			}
-			f.loadedNodes[path] = true
+		// the file is decoded by the loader, so every format registered on it can be included, the format is
+		// detected from the extension unless given explicitly. The loader only loads a file once while resolving.
+		if formatNode != nil {
+			err = loader.LoadAs(path, strings.TrimSpace(formatNode.Value()), contents)
+		} else {
+			err = loader.Load(path, contents)
+		}
+		if err != nil {
+			return nil, gofigure.NewNodeError(pathNode, fmt.Errorf("unable to load file %q: %w", path, err))
 		}
 
 		dotPath := filepath.Clean(path)
@@ -73,4 +73,30 @@ value: !include
 		Expect(loader.Get(context.Background(), "app.value", &value)).To(BeNil())
 		Expect(value).To(Equal("John"))
 	})
+
+	It("should include files of every registered format", func() {
+		fs := memfs.New()
+		Expect(fs.WriteFile("external.toml", []byte(`[name]
+first = "John"`), 0644)).To(BeNil())
+		Expect(fs.WriteFile("external.txt", []byte(`{"name": {"last": "Doe"}}`), 0644)).To(BeNil())
+		loader := gofigure.New().WithFeatures(
+			feature.Include(fs),
+		)
+		Expect(loader.Load("app.yaml", []byte(`first: !include
+  file:
+    path: external.toml
+    parse: true
+    key: name.first
+last: !include
+  file:
+    path: external.txt
+    parse: true
+    format: application/json
+    key: name.last`))).To(BeNil())
+		var first, last string
+		Expect(loader.Get(context.Background(), "app.first", &first)).To(BeNil())
+		Expect(loader.Get(context.Background(), "app.last", &last)).To(BeNil())
+		Expect(first).To(Equal("John"))
+		Expect(last).To(Equal("Doe"))
+	})
 })
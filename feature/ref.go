@@ -20,6 +20,11 @@ func (referenceFeature) Name() string {
 	return "!ref"
 }
 
+// Cacheable reports true, as a reference only depends on the value it refers to.
+func (referenceFeature) Cacheable() bool {
+	return true
+}
+
 func (t *referenceFeature) Resolve(ctx context.Context, loader *gofigure.Loader, node *gofigure.Node) (*gofigure.Node, error) {
 	if node.Kind() != yaml.ScalarNode {
 		return nil, fmt.Errorf("!ref only supports scalar node")
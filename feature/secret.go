@@ -0,0 +1,99 @@
+package feature
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/joesonw/gofigure"
+)
+
+// ErrSecretNotFound is returned by a SecretProvider that has no secret for a key.
+var ErrSecretNotFound = errors.New("secret not found")
+
+// SecretProvider looks secrets up by key, e.g. "db/password".
+type SecretProvider interface {
+	// Name returns the name secrets select the provider by, e.g. "file".
+	Name() string
+	// Secret returns the secret for key, or ErrSecretNotFound.
+	Secret(ctx context.Context, key string) (string, error)
+}
+
+type secretFeature struct {
+	providers []SecretProvider
+}
+
+// Secret resolves !secret nodes through providers, either `!secret KEY`, which asks every provider in order until one
+// has the secret, or a mapping of key and provider, which only asks the provider of that name. Secrets are strings,
+// and their nodes are marked as sensitive.
+func Secret(providers ...SecretProvider) gofigure.Feature {
+	return &secretFeature{
+		providers: providers,
+	}
+}
+
+func (*secretFeature) Name() string {
+	return "!secret"
+}
+
+func (f *secretFeature) Resolve(ctx context.Context, _ *gofigure.Loader, node *gofigure.Node) (*gofigure.Node, error) {
+	var key, provider string
+	switch node.Kind() {
+	case yaml.ScalarNode:
+		key = strings.TrimSpace(node.Value())
+	case yaml.MappingNode:
+		for _, name := range node.MappingKeys() {
+			child, err := node.GetMappingChild(name)
+			if err != nil {
+				return nil, gofigure.NewNodeError(node, err)
+			}
+			if child.Kind() != yaml.ScalarNode {
+				return nil, gofigure.NewNodeError(child, fmt.Errorf("key %q must be a scalar", name))
+			}
+
+			switch name {
+			case "key":
+				key = strings.TrimSpace(child.Value())
+			case "provider":
+				provider = strings.TrimSpace(child.Value())
+			default:
+				return nil, gofigure.NewNodeError(child, fmt.Errorf("unknown key %q, expected key or provider", name))
+			}
+		}
+	default:
+		return nil, fmt.Errorf("!secret only supports scalar and mapping nodes")
+	}
+
+	if key == "" {
+		return nil, gofigure.NewNodeError(node, fmt.Errorf("!secret requires a key"))
+	}
+
+	found := false
+	for _, p := range f.providers {
+		if provider != "" && p.Name() != provider {
+			continue
+		}
+		found = true
+
+		value, err := p.Secret(ctx, key)
+		if errors.Is(err, ErrSecretNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, gofigure.NewNodeError(node, fmt.Errorf("unable to get secret %q from %s: %w", key, p.Name(), err))
+		}
+		return gofigure.NewNode(
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value},
+			gofigure.NodeSource("secret:"+p.Name()+":"+key),
+			gofigure.NodeSensitive(),
+		), nil
+	}
+
+	if provider != "" && !found {
+		return nil, gofigure.NewNodeError(node, fmt.Errorf("unknown secret provider %q", provider))
+	}
+	return nil, gofigure.NewNodeError(node, fmt.Errorf("%w: %q", ErrSecretNotFound, key))
+}
@@ -0,0 +1,155 @@
+package feature
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	iofs "io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"unicode"
+)
+
+type fileSecrets struct {
+	fs iofs.FS
+}
+
+// FileSecrets is the "file" provider, which reads the secret of key from the file of that name in fs, such as the
+// secrets Docker and Kubernetes mount in /run/secrets. Trailing line breaks are trimmed.
+func FileSecrets(fs iofs.FS) SecretProvider {
+	return &fileSecrets{
+		fs: fs,
+	}
+}
+
+func (*fileSecrets) Name() string {
+	return "file"
+}
+
+func (p *fileSecrets) Secret(_ context.Context, key string) (string, error) {
+	if !iofs.ValidPath(key) {
+		return "", fmt.Errorf("invalid secret file name %q", key)
+	}
+	contents, err := iofs.ReadFile(p.fs, key)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", ErrSecretNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(contents), "\r\n"), nil
+}
+
+var _ SecretProvider = (*EnvSecretProvider)(nil)
+
+// EnvSecretProvider is the "env" provider, which reads the secret of key from an environment variable named after
+// it: upper cased, with every character other than a letter or digit replaced by an underscore, and prefixed, e.g.
+// SECRET_DB_PASSWORD for db/password.
+type EnvSecretProvider struct {
+	prefix string
+	lookup func(name string) (string, bool)
+}
+
+// EnvSecrets returns the "env" provider, prefix is prepended to variable names as is.
+func EnvSecrets(prefix string) *EnvSecretProvider {
+	return &EnvSecretProvider{
+		prefix: prefix,
+		lookup: os.LookupEnv,
+	}
+}
+
+// Lookup replaces os.LookupEnv to read variables, e.g. from a map in tests.
+func (p *EnvSecretProvider) Lookup(lookup func(name string) (string, bool)) *EnvSecretProvider {
+	p.lookup = lookup
+	return p
+}
+
+func (*EnvSecretProvider) Name() string {
+	return "env"
+}
+
+func (p *EnvSecretProvider) Secret(_ context.Context, key string) (string, error) {
+	name := strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return unicode.ToUpper(r)
+		}
+		return '_'
+	}, key)
+	value, ok := p.lookup(p.prefix + name)
+	if !ok {
+		return "", ErrSecretNotFound
+	}
+	return value, nil
+}
+
+var _ SecretProvider = (*HTTPSecretProvider)(nil)
+
+// HTTPSecretProvider is the "http" provider, which reads the secret of key with a GET request to the base URL joined
+// with key, e.g. https://vault.local/v1/secrets/db/password for db/password. The body of the response is the
+// secret, a 404 means there is none.
+type HTTPSecretProvider struct {
+	baseURL string
+	client  *http.Client
+	header  http.Header
+}
+
+// HTTPSecrets returns the "http" provider for the endpoint at baseURL.
+func HTTPSecrets(baseURL string) *HTTPSecretProvider {
+	return &HTTPSecretProvider{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  http.DefaultClient,
+		header:  http.Header{},
+	}
+}
+
+// Client replaces http.DefaultClient to send requests.
+func (p *HTTPSecretProvider) Client(client *http.Client) *HTTPSecretProvider {
+	p.client = client
+	return p
+}
+
+// Header sets a header on every request, e.g. Authorization.
+func (p *HTTPSecretProvider) Header(key, value string) *HTTPSecretProvider {
+	p.header.Set(key, value)
+	return p
+}
+
+func (*HTTPSecretProvider) Name() string {
+	return "http"
+}
+
+func (p *HTTPSecretProvider) Secret(ctx context.Context, key string) (string, error) {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.baseURL+"/"+strings.Join(segments, "/"), nil)
+	if err != nil {
+		return "", err
+	}
+	for key, values := range p.header {
+		req.Header[key] = values
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return "", ErrSecretNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(body), "\r\n"), nil
+}
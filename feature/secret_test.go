@@ -0,0 +1,127 @@
+package feature_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/psanford/memfs"
+
+	"github.com/joesonw/gofigure"
+	"github.com/joesonw/gofigure/feature"
+)
+
+type failingSecrets struct{}
+
+func (failingSecrets) Name() string {
+	return "failing"
+}
+
+func (failingSecrets) Secret(context.Context, string) (string, error) {
+	return "", errors.New("unavailable")
+}
+
+var _ = Describe("!secret", func() {
+	var fs *memfs.FS
+	var env *feature.EnvSecretProvider
+	var server *httptest.Server
+
+	BeforeEach(func() {
+		fs = memfs.New()
+		Expect(fs.MkdirAll("db", 0755)).To(BeNil())
+		Expect(fs.WriteFile("db/password", []byte("from-file\n"), 0600)).To(BeNil())
+
+		environ := map[string]string{"SECRET_DB_PASSWORD": "from-env", "SECRET_API_TOKEN": "0123"}
+		env = feature.EnvSecrets("SECRET_").Lookup(func(name string) (string, bool) {
+			value, ok := environ[name]
+			return value, ok
+		})
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			switch r.URL.Path {
+			case "/v1/db/password":
+				_, _ = w.Write([]byte("from-http"))
+			case "/v1/cache/url":
+				_, _ = w.Write([]byte("redis://cache"))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		DeferCleanup(server.Close)
+	})
+
+	load := func(content string, providers ...feature.SecretProvider) *gofigure.Loader {
+		loader := gofigure.New().WithFeatures(feature.Secret(providers...))
+		Expect(loader.Load("app.yaml", []byte(content))).To(BeNil())
+		return loader
+	}
+
+	It("should ask providers in order", func() {
+		http := feature.HTTPSecrets(server.URL+"/v1/").Header("Authorization", "Bearer token")
+		loader := load(`password: !secret db/password
+token: !secret api/token
+cache: !secret cache/url`, feature.FileSecrets(fs), env, http)
+		Expect(gofigure.Get[string](context.Background(), loader, "app.password")).To(Equal("from-file"))
+		Expect(gofigure.Get[string](context.Background(), loader, "app.token")).To(Equal("0123"))
+		Expect(gofigure.Get[string](context.Background(), loader, "app.cache")).To(Equal("redis://cache"))
+
+		node, err := loader.GetNode(context.Background(), "app.token")
+		Expect(err).To(BeNil())
+		Expect(node.Sensitive()).To(BeTrue())
+		Expect(node.Tag()).To(Equal("!!str"))
+		Expect(node.Source()).To(Equal("secret:env:api/token"))
+
+		raw, err := loader.Explain(context.Background(), "app.password")
+		Expect(err).To(BeNil())
+		Expect(raw.String()).To(ContainSubstring("!secret"))
+	})
+
+	It("should ask the provider selected", func() {
+		http := feature.HTTPSecrets(server.URL+"/v1").Header("Authorization", "Bearer token")
+		loader := load(`file: !secret {key: db/password, provider: file}
+env: !secret {key: db/password, provider: env}
+http: !secret
+  key: db/password
+  provider: http`, feature.FileSecrets(fs), env, http)
+		Expect(gofigure.Get[string](context.Background(), loader, "app.file")).To(Equal("from-file"))
+		Expect(gofigure.Get[string](context.Background(), loader, "app.env")).To(Equal("from-env"))
+		Expect(gofigure.Get[string](context.Background(), loader, "app.http")).To(Equal("from-http"))
+	})
+
+	It("should fail on missing secrets", func() {
+		loader := load(`missing: !secret db/missing
+unknown: !secret {key: db/password, provider: vault}
+escape: !secret ../etc/passwd`, feature.FileSecrets(fs), env)
+		_, err := gofigure.Get[string](context.Background(), loader, "app.missing")
+		Expect(errors.Is(err, feature.ErrSecretNotFound)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring(`app.yaml@1:10 secret not found: "db/missing"`))
+
+		_, err = gofigure.Get[string](context.Background(), loader, "app.unknown")
+		Expect(err).NotTo(BeNil())
+		Expect(err.Error()).To(ContainSubstring(`unknown secret provider "vault"`))
+
+		_, err = gofigure.Get[string](context.Background(), loader, "app.escape")
+		Expect(err).NotTo(BeNil())
+		Expect(err.Error()).To(ContainSubstring(`unable to get secret "../etc/passwd" from file: invalid secret file name "../etc/passwd"`))
+	})
+
+	It("should fail on provider errors", func() {
+		unauthorized := feature.HTTPSecrets(server.URL + "/v1")
+		loader := load(`http: !secret db/password
+failing: !secret {key: db/password, provider: failing}`, unauthorized, failingSecrets{})
+		_, err := gofigure.Get[string](context.Background(), loader, "app.http")
+		Expect(err).NotTo(BeNil())
+		Expect(err.Error()).To(ContainSubstring(`unable to get secret "db/password" from http: unexpected status 401 Unauthorized`))
+
+		_, err = gofigure.Get[string](context.Background(), loader, "app.failing")
+		Expect(err).NotTo(BeNil())
+		Expect(err.Error()).To(ContainSubstring(`unable to get secret "db/password" from failing: unavailable`))
+	})
+})
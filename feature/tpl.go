@@ -46,6 +46,8 @@ func (f *TemplateFeature) Resolve(ctx context.Context, loader *gofigure.Loader,
 		return nil, fmt.Errorf("!tpl only supports scalar node")
 	}
 
+	// the result is sensitive if the template reads any sensitive value
+	sensitive := false
 	tpl, err := template.New(node.Keypath()).
 		Funcs(f.funcs).
 		Funcs(template.FuncMap{
@@ -57,8 +59,11 @@ func (f *TemplateFeature) Resolve(ctx context.Context, loader *gofigure.Loader,
 				if result == nil {
 					return "", nil
 				}
+				if result.ContainsSensitive() {
+					sensitive = true
+				}
 
-				b, err := yaml.Marshal(result)
+				b, err := yaml.Marshal(result.ToYAMLNode())
 				if err != nil {
 					return "", err
 				}
@@ -76,5 +81,9 @@ func (f *TemplateFeature) Resolve(ctx context.Context, loader *gofigure.Loader,
 		return nil, fmt.Errorf("unable to execute template: %w", err)
 	}
 
-	return gofigure.NewScalarNode(strings.TrimSpace(w.String())), nil
+	var options []gofigure.NodeOption
+	if sensitive {
+		options = append(options, gofigure.NodeSensitive())
+	}
+	return gofigure.NewScalarNode(strings.TrimSpace(w.String()), options...), nil
 }
@@ -0,0 +1,204 @@
+package gofigure
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const flagSourcePrefix = "flag:"
+
+// FlagSet is a set of command-line flags that can be bound to a Loader.
+type FlagSet interface {
+	// Visit calls fn for every flag that has been set explicitly.
+	Visit(fn func(name, value string))
+}
+
+// FlagSetFunc adapts a function to a FlagSet, e.g. for a pflag.FlagSet:
+//
+//	gofigure.FlagSetFunc(func(fn func(name, value string)) {
+//		fs.Visit(func(f *pflag.Flag) { fn(f.Name, f.Value.String()) })
+//	})
+type FlagSetFunc func(fn func(name, value string))
+
+func (f FlagSetFunc) Visit(fn func(name, value string)) {
+	f(fn)
+}
+
+// StdFlagSet adapts a flag.FlagSet to a FlagSet.
+func StdFlagSet(fs *flag.FlagSet) FlagSet {
+	return FlagSetFunc(func(fn func(name, value string)) {
+		fs.Visit(func(f *flag.Flag) {
+			fn(f.Name, f.Value.String())
+		})
+	})
+}
+
+type boundFlagSet struct {
+	fs     FlagSet
+	prefix string
+}
+
+type FlagOption interface {
+	apply(*boundFlagSet)
+}
+
+type flagOptionFunc func(*boundFlagSet)
+
+func (f flagOptionFunc) apply(o *boundFlagSet) {
+	f(o)
+}
+
+// FlagPrefix only binds flags starting with prefix, which is stripped to get the dot path, e.g. "config." binds
+// -config.storage.db.host to storage.db.host.
+func FlagPrefix(prefix string) FlagOption {
+	return flagOptionFunc(func(o *boundFlagSet) {
+		o.prefix = prefix
+	})
+}
+
+// BindFlags binds a set of flags named after dot paths. Flags that have been set explicitly override the values at
+// their paths, whether the files are loaded before or after the flags are bound. The flags are read when the config is
+// first read after they are bound or a file is loaded, flags parsed after that are picked up by Reload.
+func (l *Loader) BindFlags(fs FlagSet, options ...FlagOption) *Loader {
+	b := &boundFlagSet{fs: fs}
+	for i := range options {
+		options[i].apply(b)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.flagSets = append(l.flagSets[:len(l.flagSets):len(l.flagSets)], b)
+	l.snapshot.Store(nil)
+	return l
+}
+
+// RegisterFlags defines a flag for every scalar value under path (the whole config if empty) and binds fs. Flags are
+// named after the dot path of the values, default to the resolved values and are documented by the head comments of
+// their keys. The defaults of sensitive values are Redacted, so they are not printed by -help. Flags already defined in
+// fs are left untouched.
+func (l *Loader) RegisterFlags(ctx context.Context, fs *flag.FlagSet, path string, options ...FlagOption) error {
+	b := &boundFlagSet{}
+	for i := range options {
+		options[i].apply(b)
+	}
+
+	node, err := l.GetNode(ctx, path)
+	if err != nil {
+		return err
+	}
+	if node == nil {
+		return fmt.Errorf("%s: %w", path, ErrPathNotFound)
+	}
+
+	walkScalars(node, path, func(path string, node, value *Node) {
+		name := b.prefix + path
+		if fs.Lookup(name) != nil {
+			return
+		}
+
+		usage := flagUsage(node.KeyHeadComment())
+		if usage == "" {
+			usage = flagUsage(value.HeadComment())
+		}
+		defaultValue := value.Value()
+		if value.Sensitive() {
+			defaultValue = Redacted
+		}
+		fs.Var(&nodeFlagValue{
+			value:  defaultValue,
+			isBool: value.Tag() == "!!bool",
+		}, name, usage)
+	})
+
+	l.BindFlags(StdFlagSet(fs), options...)
+	return nil
+}
+
+// walkScalars calls fn for every scalar under a resolved node, in key order, with both the node and its resolved value.
+func walkScalars(node *Node, path string, fn func(path string, node, value *Node)) {
+	value := resolvedValue(node)
+	switch value.kind {
+	case yaml.MappingNode:
+		for _, key := range sortedKeys(value.mappingNodes) {
+			childPath := key
+			if path != "" {
+				childPath = path + "." + key
+			}
+			walkScalars(value.mappingNodes[key], childPath, fn)
+		}
+	case yaml.SequenceNode:
+		for i, child := range value.sequenceNodes {
+			walkScalars(child, fmt.Sprintf("%s[%d]", path, i), fn)
+		}
+	case yaml.ScalarNode:
+		fn(path, node, value)
+	}
+}
+
+// flagsNode returns the overlay of the explicitly set flags, along with a fingerprint of their values.
+func (l *Loader) flagsNode() (*Node, string, error) {
+	values := flagValues(l.flagSets)
+
+	flagsNode := NewMappingNode(map[string]*Node{}, NodeSource("flags"))
+	for i := 0; i < len(values); i += 2 {
+		paths, err := ParseDotPath(values[i])
+		if err != nil {
+			return nil, "", fmt.Errorf("unable to bind flag %q: %w", values[i], err)
+		}
+		if len(paths) == 0 || paths[0].Key == "" {
+			return nil, "", fmt.Errorf("unable to bind flag %q: %w", values[i], ErrInvalidPath)
+		}
+		if err := setOverlayValue(flagsNode, paths, values[i+1], flagSourcePrefix+values[i]); err != nil {
+			return nil, "", fmt.Errorf("unable to bind flag %q: %w", values[i], err)
+		}
+	}
+
+	return flagsNode, strings.Join(values, "\x00"), nil
+}
+
+// flagValues returns the dot paths and values of the explicitly set flags, in pairs.
+func flagValues(flagSets []*boundFlagSet) []string {
+	var values []string
+	for _, b := range flagSets {
+		b.fs.Visit(func(name, value string) {
+			if strings.HasPrefix(name, b.prefix) {
+				values = append(values, strings.TrimPrefix(name, b.prefix), value)
+			}
+		})
+	}
+	return values
+}
+
+// nodeFlagValue is the flag.Value of flags registered from the config.
+type nodeFlagValue struct {
+	value  string
+	isBool bool
+}
+
+func (v *nodeFlagValue) String() string {
+	if v == nil {
+		return ""
+	}
+	return v.value
+}
+
+func (v *nodeFlagValue) Set(value string) error {
+	v.value = value
+	return nil
+}
+
+func (v *nodeFlagValue) IsBoolFlag() bool {
+	return v.isBool
+}
+
+// flagUsage strips the comment markers from a YAML comment.
+func flagUsage(comment string) string {
+	lines := strings.Split(comment, "\n")
+	for i := range lines {
+		lines[i] = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(lines[i]), "#"))
+	}
+	return strings.TrimSpace(strings.Join(lines, " "))
+}
@@ -0,0 +1,162 @@
+package gofigure
+
+import (
+	"context"
+	"flag"
+	"io"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Flag", func() {
+	It("should override with explicitly set flags", func() {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		host := fs.String("storage.db.host", "default-host", "")
+		fs.Int("storage.db.port", 1, "")
+		fs.Bool("storage.db.debug", false, "")
+
+		loader := New().BindFlags(StdFlagSet(fs))
+		Expect(loader.Load("storage/db.yaml", []byte(`host: localhost
+port: 3306`))).To(BeNil())
+		Expect(fs.Parse([]string{"-storage.db.host=remote-address", "-storage.db.debug"})).To(BeNil())
+		Expect(*host).To(Equal("remote-address"))
+
+		var db struct {
+			Host  string `yaml:"host"`
+			Port  int    `yaml:"port"`
+			Debug bool   `yaml:"debug"`
+		}
+		Expect(loader.Get(context.Background(), "storage.db", &db)).To(BeNil())
+		Expect(db.Host).To(Equal("remote-address"))
+		Expect(db.Port).To(Equal(3306))
+		Expect(db.Debug).To(BeTrue())
+
+		// flags stay on top of files loaded later
+		Expect(loader.Load("storage/db.yaml", []byte(`host: another-host
+port: 3307`))).To(BeNil())
+		Expect(loader.Get(context.Background(), "storage.db", &db)).To(BeNil())
+		Expect(db.Host).To(Equal("remote-address"))
+		Expect(db.Port).To(Equal(3307))
+
+		hostNode, err := loader.GetNode(context.Background(), "storage.db.host")
+		Expect(err).To(BeNil())
+		Expect(hostNode.Source()).To(Equal("flag:storage.db.host"))
+	})
+
+	It("should read flags parsed after the config is read on reload", func() {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.Int("app.port", 0, "")
+		loader := New().BindFlags(StdFlagSet(fs))
+		Expect(loader.Load("app.yaml", []byte(`port: 8080`))).To(BeNil())
+
+		var port int
+		Expect(loader.Get(context.Background(), "app.port", &port)).To(BeNil())
+		Expect(port).To(Equal(8080))
+
+		Expect(fs.Parse([]string{"-app.port", "9090"})).To(BeNil())
+		Expect(loader.Get(context.Background(), "app.port", &port)).To(BeNil())
+		Expect(port).To(Equal(8080))
+
+		changed, err := loader.Reload(context.Background())
+		Expect(err).To(BeNil())
+		Expect(changed).To(Equal([]string{"app.port"}))
+		Expect(loader.Get(context.Background(), "app.port", &port)).To(BeNil())
+		Expect(port).To(Equal(9090))
+	})
+
+	It("should bind flags with prefix", func() {
+		loader := New().BindFlags(FlagSetFunc(func(fn func(name, value string)) {
+			fn("config.app.servers[1].port", "8081")
+			fn("verbose", "true")
+		}), FlagPrefix("config."))
+		Expect(loader.Load("app.yaml", []byte(`servers:
+- port: 80
+- port: 81`))).To(BeNil())
+
+		var ports []struct {
+			Port int `yaml:"port"`
+		}
+		Expect(loader.Get(context.Background(), "app.servers", &ports)).To(BeNil())
+		Expect(ports).To(HaveLen(2))
+		Expect(ports[0].Port).To(Equal(80))
+		Expect(ports[1].Port).To(Equal(8081))
+
+		node, err := loader.GetNode(context.Background(), "verbose")
+		Expect(err).To(BeNil())
+		Expect(node).To(BeNil())
+	})
+
+	It("should fail to bind flags with invalid path", func() {
+		loader := New().BindFlags(FlagSetFunc(func(fn func(name, value string)) {
+			fn("app..port", "80")
+		}))
+		_, err := loader.GetNode(context.Background(), "app")
+		Expect(err).To(MatchError(ErrInvalidPath))
+	})
+
+	It("should register flags from config", func() {
+		loader := New()
+		Expect(loader.Load("app.yaml", []byte(`# port to listen on
+port: 8080
+# enable debug mode
+debug: false
+tags: [a, b]
+db:
+  host: localhost`))).To(BeNil())
+
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		fs.String("app.db.host", "unchanged", "defined before")
+		Expect(loader.RegisterFlags(context.Background(), fs, "app")).To(BeNil())
+
+		port := fs.Lookup("app.port")
+		Expect(port).NotTo(BeNil())
+		Expect(port.DefValue).To(Equal("8080"))
+		Expect(port.Usage).To(Equal("port to listen on"))
+		Expect(fs.Lookup("app.debug").Usage).To(Equal("enable debug mode"))
+		Expect(fs.Lookup("app.tags[1]").DefValue).To(Equal("b"))
+		Expect(fs.Lookup("app.db.host").DefValue).To(Equal("unchanged"))
+
+		Expect(fs.Parse([]string{"-app.port", "9090", "-app.debug"})).To(BeNil())
+
+		var app struct {
+			Port  int    `yaml:"port"`
+			Debug bool   `yaml:"debug"`
+			Host  string `yaml:"host"`
+		}
+		Expect(loader.Get(context.Background(), "app", &app)).To(BeNil())
+		Expect(app.Port).To(Equal(9090))
+		Expect(app.Debug).To(BeTrue())
+	})
+
+	It("should not print the defaults of sensitive values", func() {
+		loader := New().WithSensitivePaths("**.token")
+		Expect(loader.Load("app.yaml", []byte(`password: !sensitive hunter2
+token: abc123
+port: 8080`))).To(BeNil())
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		Expect(loader.RegisterFlags(context.Background(), fs, "app")).To(BeNil())
+		Expect(fs.Lookup("app.password").DefValue).To(Equal(Redacted))
+		Expect(fs.Lookup("app.token").DefValue).To(Equal(Redacted))
+		Expect(fs.Lookup("app.port").DefValue).To(Equal("8080"))
+
+		var usage strings.Builder
+		fs.SetOutput(&usage)
+		fs.PrintDefaults()
+		Expect(usage.String()).NotTo(ContainSubstring("hunter2"))
+		Expect(usage.String()).NotTo(ContainSubstring("abc123"))
+
+		password, err := Get[string](context.Background(), loader, "app.password")
+		Expect(err).To(BeNil())
+		Expect(password).To(Equal("hunter2"))
+	})
+
+	It("should fail to register flags for missing path", func() {
+		loader := New()
+		Expect(loader.Load("app.yaml", []byte(`port: 8080`))).To(BeNil())
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		Expect(loader.RegisterFlags(context.Background(), fs, "app.missing")).To(MatchError(ErrPathNotFound))
+	})
+})
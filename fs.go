@@ -0,0 +1,120 @@
+package gofigure
+
+import (
+	"errors"
+	"fmt"
+	iofs "io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// LoadFS loads every file under the root of fsys that a decoder is registered for, each file is nested under the keys
+// of its path, e.g. storage/db.yaml is loaded at storage.db. The directories of the active profiles are then loaded in
+// order on top of the base files, at the same keys, so prod/storage/db.yaml overrides storage/db.yaml. Directories of
+// inactive known profiles, as well as hidden files and directories, are skipped. The directories are walked again on
+// reload, so files added or removed since are picked up.
+func (l *Loader) LoadFS(fsys iofs.FS, options ...FSOption) error {
+	o := defaultFSOptions()
+	for i := range options {
+		options[i].apply(o)
+	}
+
+	return l.addSource(func(into *Loader) error {
+		return into.loadFS(fsys, o)
+	}, func() (string, error) {
+		return stampFS(fsys, o.root)
+	})
+}
+
+// stampFS returns the paths, sizes and modification times of the files under root, which change along with the files
+// in most cases, so they are only read again once it has changed.
+func stampFS(fsys iofs.FS, root string) (string, error) {
+	var sb strings.Builder
+	err := iofs.WalkDir(fsys, root, func(name string, d iofs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&sb, "%s %d %d\n", name, info.Size(), info.ModTime().UnixNano())
+		return nil
+	})
+	return sb.String(), err
+}
+
+func (l *Loader) loadFS(fsys iofs.FS, o *fsOptions) error {
+	profileDirs := map[string]bool{}
+	for _, profile := range append(append([]string(nil), o.profiles...), o.knownProfiles...) {
+		profileDirs[path.Join(o.root, o.profileDir(profile))] = true
+	}
+
+	if err := l.loadDir(fsys, o.root, profileDirs); err != nil {
+		return err
+	}
+
+	for _, profile := range o.profiles {
+		dir := path.Join(o.root, o.profileDir(profile))
+		if _, err := iofs.Stat(fsys, dir); errors.Is(err, iofs.ErrNotExist) {
+			continue
+		}
+		if err := l.loadDir(fsys, dir, profileDirs); err != nil {
+			return fmt.Errorf("unable to load profile %q: %w", profile, err)
+		}
+	}
+
+	return nil
+}
+
+// loadDir loads the files under dir, nested under their paths relative to dir.
+func (l *Loader) loadDir(fsys iofs.FS, dir string, skipDirs map[string]bool) error {
+	return iofs.WalkDir(fsys, dir, func(name string, d iofs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if name != dir && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return iofs.SkipDir
+			}
+			return nil
+		}
+
+		if d.IsDir() {
+			if name != dir && skipDirs[name] {
+				return iofs.SkipDir
+			}
+			return nil
+		}
+
+		format := path.Ext(name)
+		if l.decoder(format) == nil {
+			return nil
+		}
+
+		contents, err := iofs.ReadFile(fsys, name)
+		if err != nil {
+			return fmt.Errorf("unable to read file %q: %w", name, err)
+		}
+
+		rel := strings.TrimPrefix(strings.TrimPrefix(name, dir), "/")
+		if dir == "." {
+			rel = name
+		}
+		keypath := strings.TrimSuffix(rel, format)
+		return l.load(filepath.FromSlash(name), filepath.FromSlash(keypath), format, contents)
+	})
+}
+
+// ParseProfiles splits a comma separated list of profiles, e.g. "prod,eu-west".
+func ParseProfiles(s string) []string {
+	var profiles []string
+	for _, profile := range strings.Split(s, ",") {
+		if profile = strings.TrimSpace(profile); profile != "" {
+			profiles = append(profiles, profile)
+		}
+	}
+	return profiles
+}
@@ -0,0 +1,56 @@
+package gofigure
+
+type fsOptions struct {
+	root          string
+	profiles      []string
+	knownProfiles []string
+	profileDir    func(profile string) string
+}
+
+func defaultFSOptions() *fsOptions {
+	return &fsOptions{
+		root: ".",
+		profileDir: func(profile string) string {
+			return profile
+		},
+	}
+}
+
+type FSOption interface {
+	apply(*fsOptions)
+}
+
+type fsOptionFunc func(*fsOptions)
+
+func (f fsOptionFunc) apply(o *fsOptions) {
+	f(o)
+}
+
+// FSRoot sets the directory to load, "." by default.
+func FSRoot(root string) FSOption {
+	return fsOptionFunc(func(o *fsOptions) {
+		o.root = root
+	})
+}
+
+// FSProfiles sets the active profiles, their directories are loaded on top of the base files in the order given.
+func FSProfiles(profiles ...string) FSOption {
+	return fsOptionFunc(func(o *fsOptions) {
+		o.profiles = append(o.profiles, profiles...)
+	})
+}
+
+// FSKnownProfiles sets profiles that are not active, so their directories are skipped instead of being loaded as base
+// files.
+func FSKnownProfiles(profiles ...string) FSOption {
+	return fsOptionFunc(func(o *fsOptions) {
+		o.knownProfiles = append(o.knownProfiles, profiles...)
+	})
+}
+
+// FSProfileDir maps a profile to its directory relative to the root, the profile name itself by default.
+func FSProfileDir(dir func(profile string) string) FSOption {
+	return fsOptionFunc(func(o *fsOptions) {
+		o.profileDir = dir
+	})
+}
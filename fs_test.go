@@ -0,0 +1,106 @@
+package gofigure
+
+import (
+	"context"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/psanford/memfs"
+)
+
+func newConfigFS() *memfs.FS {
+	fs := memfs.New()
+	Expect(fs.MkdirAll("config/storage", 0755)).To(BeNil())
+	Expect(fs.MkdirAll("config/prod/storage", 0755)).To(BeNil())
+	Expect(fs.MkdirAll("config/eu-west/storage", 0755)).To(BeNil())
+	Expect(fs.MkdirAll("config/staging", 0755)).To(BeNil())
+	Expect(fs.MkdirAll("config/.git", 0755)).To(BeNil())
+	Expect(fs.WriteFile("config/app.yaml", []byte(`env: dev
+port: 8080`), 0644)).To(BeNil())
+	Expect(fs.WriteFile("config/README.md", []byte(`# not a config file`), 0644)).To(BeNil())
+	Expect(fs.WriteFile("config/.git/config.yaml", []byte(`hidden: true`), 0644)).To(BeNil())
+	Expect(fs.WriteFile("config/storage/db.yaml", []byte(`host: localhost
+port: 3306
+region: local`), 0644)).To(BeNil())
+	Expect(fs.WriteFile("config/prod/app.yaml", []byte(`env: prod
+port: 80`), 0644)).To(BeNil())
+	Expect(fs.WriteFile("config/prod/storage/db.json", []byte(`{"host": "remote-address"}`), 0644)).To(BeNil())
+	Expect(fs.WriteFile("config/eu-west/storage/db.toml", []byte(`region = "eu-west-1"`), 0644)).To(BeNil())
+	Expect(fs.WriteFile("config/staging/app.yaml", []byte(`env: staging`), 0644)).To(BeNil())
+	return fs
+}
+
+var _ = Describe("LoadFS", func() {
+	type db struct {
+		Host   string `yaml:"host"`
+		Port   int    `yaml:"port"`
+		Region string `yaml:"region"`
+	}
+
+	It("should load base files", func() {
+		loader := New()
+		Expect(loader.LoadFS(newConfigFS(), FSRoot("config"), FSKnownProfiles("prod", "eu-west", "staging"))).To(BeNil())
+
+		var env string
+		Expect(loader.Get(context.Background(), "app.env", &env)).To(BeNil())
+		Expect(env).To(Equal("dev"))
+
+		var value db
+		Expect(loader.Get(context.Background(), "storage.db", &value)).To(BeNil())
+		Expect(value).To(Equal(db{Host: "localhost", Port: 3306, Region: "local"}))
+
+		Expect(loader.root.mappingNodes).To(HaveLen(2))
+		Expect(loader.root.mappingNodes).To(HaveKey("app"))
+		Expect(loader.root.mappingNodes).To(HaveKey("storage"))
+	})
+
+	It("should load stacked profiles", func() {
+		loader := New()
+		Expect(loader.LoadFS(newConfigFS(), FSRoot("config"), FSProfiles(ParseProfiles("prod, eu-west,")...), FSKnownProfiles("staging"))).To(BeNil())
+
+		var env string
+		Expect(loader.Get(context.Background(), "app.env", &env)).To(BeNil())
+		Expect(env).To(Equal("prod"))
+
+		var value db
+		Expect(loader.Get(context.Background(), "storage.db", &value)).To(BeNil())
+		Expect(value).To(Equal(db{Host: "remote-address", Port: 3306, Region: "eu-west-1"}))
+
+		hostNode, err := loader.GetNode(context.Background(), "storage.db.host")
+		Expect(err).To(BeNil())
+		Expect(hostNode.Filepath()).To(Equal(filepath.FromSlash("config/prod/storage/db.json")))
+		Expect(loader.root.mappingNodes).NotTo(HaveKey("staging"))
+	})
+
+	It("should load profiles from mapped directories and skip missing ones", func() {
+		fs := memfs.New()
+		Expect(fs.MkdirAll("profiles/prod", 0755)).To(BeNil())
+		Expect(fs.WriteFile("app.yaml", []byte(`env: dev`), 0644)).To(BeNil())
+		Expect(fs.WriteFile("profiles/prod/app.yaml", []byte(`env: prod`), 0644)).To(BeNil())
+
+		loader := New()
+		Expect(loader.LoadFS(fs, FSProfiles("prod", "missing"), FSProfileDir(func(profile string) string {
+			return "profiles/" + profile
+		}))).To(BeNil())
+
+		var env string
+		Expect(loader.Get(context.Background(), "app.env", &env)).To(BeNil())
+		Expect(env).To(Equal("prod"))
+		Expect(loader.root.mappingNodes).NotTo(HaveKey("profiles"))
+	})
+
+	It("should fail with invalid files", func() {
+		fs := memfs.New()
+		Expect(fs.WriteFile("app.json", []byte(`{`), 0644)).To(BeNil())
+		Expect(New().LoadFS(fs)).To(MatchError(ErrConfigParseError))
+	})
+})
+
+var _ = DescribeTable("ParseProfiles", func(s string, profiles []string) {
+	Expect(ParseProfiles(s)).To(Equal(profiles))
+},
+	Entry("empty", "", []string(nil)),
+	Entry("single", "prod", []string{"prod"}),
+	Entry("multiple", " prod , eu-west ,,", []string{"prod", "eu-west"}),
+)
@@ -0,0 +1,77 @@
+package gofigure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Get returns the resolved value at path decoded into a T, see Loader.Get. It returns ErrPathNotFound if there is no
+// value at path.
+func Get[T any](ctx context.Context, loader *Loader, path string) (T, error) {
+	var value T
+	node, err := loader.GetNode(ctx, path)
+	if err != nil {
+		return value, err
+	}
+	if node == nil {
+		return value, fmt.Errorf("%s: %w", path, ErrPathNotFound)
+	}
+	err = decode(loader.decodeHooks, node, nil, path, &value)
+	return value, err
+}
+
+// MustGet is like Get but panics if the value is missing or cannot be decoded.
+func MustGet[T any](ctx context.Context, loader *Loader, path string) T {
+	value, err := Get[T](ctx, loader, path)
+	if err != nil {
+		panic(err)
+	}
+	return value
+}
+
+// GetOr is like Get but returns defaultValue if there is no value at path. Values that cannot be decoded are still
+// an error.
+func GetOr[T any](ctx context.Context, loader *Loader, path string, defaultValue T) (T, error) {
+	value, err := Get[T](ctx, loader, path)
+	if errors.Is(err, ErrPathNotFound) {
+		return defaultValue, nil
+	}
+	return value, err
+}
+
+// GetString returns the value at path as a string.
+func (l *Loader) GetString(ctx context.Context, path string) (string, error) {
+	return Get[string](ctx, l, path)
+}
+
+// GetInt returns the value at path as an int.
+func (l *Loader) GetInt(ctx context.Context, path string) (int, error) {
+	return Get[int](ctx, l, path)
+}
+
+// GetFloat returns the value at path as a float64.
+func (l *Loader) GetFloat(ctx context.Context, path string) (float64, error) {
+	return Get[float64](ctx, l, path)
+}
+
+// GetBool returns the value at path as a bool.
+func (l *Loader) GetBool(ctx context.Context, path string) (bool, error) {
+	return Get[bool](ctx, l, path)
+}
+
+// GetDuration returns the value at path as a time.Duration, written the way time.ParseDuration reads it, e.g. 1m30s.
+func (l *Loader) GetDuration(ctx context.Context, path string) (time.Duration, error) {
+	return Get[time.Duration](ctx, l, path)
+}
+
+// GetByteSize returns the value at path as a ByteSize, e.g. 512, 64KB or 1.5GiB.
+func (l *Loader) GetByteSize(ctx context.Context, path string) (ByteSize, error) {
+	return Get[ByteSize](ctx, l, path)
+}
+
+// GetStringSlice returns the sequence at path as a []string.
+func (l *Loader) GetStringSlice(ctx context.Context, path string) ([]string, error) {
+	return Get[[]string](ctx, l, path)
+}
@@ -0,0 +1,100 @@
+package gofigure
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Get", func() {
+	var loader *Loader
+	ctx := context.Background()
+
+	BeforeEach(func() {
+		loader = New()
+		Expect(loader.Load("app.yaml", []byte(`name: api
+port: 8080
+ratio: 0.5
+debug: true
+timeout: 1m30s
+size: 64MiB
+hosts: [a, b]
+server:
+  host: localhost
+invalid: eighty`))).To(BeNil())
+	})
+
+	It("should get typed values", func() {
+		port, err := Get[int](ctx, loader, "app.port")
+		Expect(err).To(BeNil())
+		Expect(port).To(Equal(8080))
+
+		server, err := Get[map[string]string](ctx, loader, "app.server")
+		Expect(err).To(BeNil())
+		Expect(server).To(Equal(map[string]string{"host": "localhost"}))
+
+		type Server struct {
+			Host string `yaml:"host"`
+			Port int    `yaml:"port" default:"80"`
+		}
+		Expect(Get[Server](ctx, loader, "app.server")).To(Equal(Server{Host: "localhost", Port: 80}))
+	})
+
+	It("should fail on missing values", func() {
+		_, err := Get[int](ctx, loader, "app.missing")
+		Expect(errors.Is(err, ErrPathNotFound)).To(BeTrue())
+		Expect(err.Error()).To(Equal("app.missing: path not found"))
+	})
+
+	It("should fail with the position of values that cannot be converted", func() {
+		_, err := Get[int](ctx, loader, "app.invalid")
+		Expect(err).NotTo(BeNil())
+		Expect(err.Error()).To(Equal("app.yaml:10:10: app.invalid: cannot unmarshal !!str `eighty` into int"))
+		var decodeError *DecodeError
+		Expect(errors.As(err, &decodeError)).To(BeTrue())
+		Expect(decodeError.Line).To(Equal(10))
+	})
+
+	It("should MustGet", func() {
+		Expect(MustGet[string](ctx, loader, "app.name")).To(Equal("api"))
+		Expect(func() { MustGet[int](ctx, loader, "app.missing") }).To(PanicWith(MatchError(ErrPathNotFound)))
+	})
+
+	It("should GetOr", func() {
+		Expect(GetOr(ctx, loader, "app.port", 80)).To(Equal(8080))
+		Expect(GetOr(ctx, loader, "app.missing", 80)).To(Equal(80))
+		_, err := GetOr(ctx, loader, "app.invalid", 80)
+		Expect(err).NotTo(BeNil())
+	})
+
+	It("should get with shortcuts", func() {
+		Expect(loader.GetString(ctx, "app.name")).To(Equal("api"))
+		Expect(loader.GetInt(ctx, "app.port")).To(Equal(8080))
+		Expect(loader.GetFloat(ctx, "app.ratio")).To(Equal(0.5))
+		Expect(loader.GetBool(ctx, "app.debug")).To(BeTrue())
+		Expect(loader.GetDuration(ctx, "app.timeout")).To(Equal(90 * time.Second))
+		Expect(loader.GetByteSize(ctx, "app.size")).To(Equal(ByteSize(64 << 20)))
+		Expect(loader.GetStringSlice(ctx, "app.hosts")).To(Equal([]string{"a", "b"}))
+	})
+
+	It("should fail shortcuts with the position of values that cannot be converted", func() {
+		_, err := loader.GetDuration(ctx, "app.invalid")
+		Expect(err).NotTo(BeNil())
+		Expect(err.Error()).To(Equal("app.yaml:10:10: app.invalid: invalid duration \"eighty\""))
+
+		_, err = loader.GetByteSize(ctx, "app.invalid")
+		Expect(err).NotTo(BeNil())
+		Expect(err.Error()).To(Equal(`app.yaml:10:10: app.invalid: invalid byte size "eighty"`))
+
+		_, err = loader.GetBool(ctx, "app.port")
+		Expect(err).NotTo(BeNil())
+		Expect(err.Error()).To(Equal("app.yaml:2:7: app.port: cannot unmarshal !!int `8080` into bool"))
+
+		_, err = loader.GetStringSlice(ctx, "app.name")
+		Expect(err).NotTo(BeNil())
+		Expect(err.Error()).To(Equal("app.yaml:1:7: app.name: cannot unmarshal !!str `api` into []string"))
+	})
+})
@@ -0,0 +1,491 @@
+package gofigure
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// decodeJSON parses JSON contents into a node tree. Besides strict JSON it accepts the relaxed syntax of JSONC and
+// JSON5: comments, trailing commas, single quoted strings, unquoted keys, hexadecimal numbers, Infinity and NaN.
+func decodeJSON(contents []byte, options ...NodeOption) (*Node, error) {
+	p := &jsonParser{data: contents, line: 1, column: 1}
+	if err := p.skipSpaces(); err != nil {
+		return nil, err
+	}
+
+	if p.eof() {
+		n := createNodeWithOptions(options...)
+		n.kind = yaml.MappingNode
+		n.tag = "!!map"
+		n.mappingNodes = map[string]*Node{}
+		return n, nil
+	}
+
+	n := createNodeWithOptions(options...)
+	if err := p.parseValue(n); err != nil {
+		return nil, err
+	}
+
+	if err := p.skipSpaces(); err != nil {
+		return nil, err
+	}
+	if !p.eof() {
+		return nil, p.errorf("unexpected %q after top-level value", p.peek())
+	}
+	return n, nil
+}
+
+type jsonParser struct {
+	data   []byte
+	pos    int
+	line   int
+	column int
+}
+
+func (p *jsonParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("%d:%d: %s", p.line, p.column, fmt.Sprintf(format, args...))
+}
+
+func (p *jsonParser) eof() bool {
+	return p.pos >= len(p.data)
+}
+
+func (p *jsonParser) peek() rune {
+	r, _ := utf8.DecodeRune(p.data[p.pos:])
+	return r
+}
+
+func (p *jsonParser) next() rune {
+	r, size := utf8.DecodeRune(p.data[p.pos:])
+	p.pos += size
+	if r == '\n' {
+		p.line++
+		p.column = 1
+	} else {
+		p.column++
+	}
+	return r
+}
+
+func (p *jsonParser) hasPrefix(prefix string) bool {
+	return strings.HasPrefix(string(p.data[p.pos:]), prefix)
+}
+
+// skipSpaces skips whitespaces as well as line and block comments.
+func (p *jsonParser) skipSpaces() error {
+	for !p.eof() {
+		switch {
+		case unicode.IsSpace(p.peek()) || p.peek() == '\uFEFF':
+			p.next()
+		case p.hasPrefix("//"):
+			for !p.eof() && p.peek() != '\n' {
+				p.next()
+			}
+		case p.hasPrefix("/*"):
+			line, column := p.line, p.column
+			p.next()
+			p.next()
+			for !p.hasPrefix("*/") {
+				if p.eof() {
+					return fmt.Errorf("%d:%d: unterminated comment", line, column)
+				}
+				p.next()
+			}
+			p.next()
+			p.next()
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+func (p *jsonParser) parseValue(n *Node) error {
+	if p.eof() {
+		return p.errorf("unexpected end of input")
+	}
+
+	n.line = p.line
+	n.column = p.column
+
+	switch r := p.peek(); {
+	case r == '{':
+		return p.parseObject(n)
+	case r == '[':
+		return p.parseArray(n)
+	case r == '"' || r == '\'':
+		s, err := p.parseString()
+		if err != nil {
+			return err
+		}
+		n.kind = yaml.ScalarNode
+		n.style = yaml.DoubleQuotedStyle
+		n.tag = "!!str"
+		n.value = s
+		return nil
+	default:
+		return p.parseLiteral(n)
+	}
+}
+
+func (p *jsonParser) parseObject(n *Node) error {
+	n.kind = yaml.MappingNode
+	n.tag = "!!map"
+	n.mappingNodes = map[string]*Node{}
+
+	p.next() // {
+	for {
+		if err := p.skipSpaces(); err != nil {
+			return err
+		}
+		if p.eof() {
+			return p.errorf("unterminated object")
+		}
+		if p.peek() == '}' {
+			p.next()
+			return nil
+		}
+
+		key, err := p.parseKey()
+		if err != nil {
+			return err
+		}
+
+		if err := p.skipSpaces(); err != nil {
+			return err
+		}
+		if p.eof() || p.peek() != ':' {
+			return p.errorf("expected ':' after object key %q", key)
+		}
+		p.next()
+		if err := p.skipSpaces(); err != nil {
+			return err
+		}
+
+		childNode := &Node{
+			parent:        n,
+			mappingKey:    key,
+			hasMappingKey: true,
+		}
+		if err := p.parseValue(childNode); err != nil {
+			return err
+		}
+		n.setMappingChild(key, childNode)
+
+		if err := p.skipSpaces(); err != nil {
+			return err
+		}
+		if p.eof() {
+			return p.errorf("unterminated object")
+		}
+		switch p.peek() {
+		case ',':
+			p.next()
+		case '}':
+		default:
+			return p.errorf("expected ',' or '}' in object, got %q", p.peek())
+		}
+	}
+}
+
+func (p *jsonParser) parseKey() (string, error) {
+	if r := p.peek(); r == '"' || r == '\'' {
+		return p.parseString()
+	}
+
+	// JSON5 allows identifiers as keys
+	start := p.pos
+	for !p.eof() && isJSONIdentifierRune(p.peek()) {
+		p.next()
+	}
+	if start == p.pos {
+		return "", p.errorf("expected object key, got %q", p.peek())
+	}
+	return string(p.data[start:p.pos]), nil
+}
+
+func (p *jsonParser) parseArray(n *Node) error {
+	n.kind = yaml.SequenceNode
+	n.tag = "!!seq"
+	n.sequenceNodes = []*Node{}
+
+	p.next() // [
+	for {
+		if err := p.skipSpaces(); err != nil {
+			return err
+		}
+		if p.eof() {
+			return p.errorf("unterminated array")
+		}
+		if p.peek() == ']' {
+			p.next()
+			return nil
+		}
+
+		childNode := &Node{
+			parent:           n,
+			sequenceIndex:    len(n.sequenceNodes),
+			hasSequenceIndex: true,
+		}
+		if err := p.parseValue(childNode); err != nil {
+			return err
+		}
+		n.sequenceNodes = append(n.sequenceNodes, childNode)
+
+		if err := p.skipSpaces(); err != nil {
+			return err
+		}
+		if p.eof() {
+			return p.errorf("unterminated array")
+		}
+		switch p.peek() {
+		case ',':
+			p.next()
+		case ']':
+		default:
+			return p.errorf("expected ',' or ']' in array, got %q", p.peek())
+		}
+	}
+}
+
+//nolint:gocyclo
+func (p *jsonParser) parseString() (string, error) {
+	quote := p.next()
+	var b strings.Builder
+	for {
+		if p.eof() {
+			return "", p.errorf("unterminated string")
+		}
+		r := p.next()
+		if r == quote {
+			return b.String(), nil
+		}
+		if r == '\n' {
+			return "", p.errorf("unexpected newline in string")
+		}
+		if r != '\\' {
+			b.WriteRune(r)
+			continue
+		}
+
+		if p.eof() {
+			return "", p.errorf("unterminated string")
+		}
+		switch esc := p.next(); esc {
+		case '"', '\'', '\\', '/':
+			b.WriteRune(esc)
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case 'v':
+			b.WriteByte('\v')
+		case '0':
+			b.WriteByte(0)
+		case '\n': // JSON5 line continuation
+		case 'x':
+			r, err := p.parseHexRune(2)
+			if err != nil {
+				return "", err
+			}
+			b.WriteRune(r)
+		case 'u':
+			r, err := p.parseHexRune(4)
+			if err != nil {
+				return "", err
+			}
+			if utf16IsHighSurrogate(r) && p.hasPrefix(`\u`) {
+				p.next()
+				p.next()
+				low, err := p.parseHexRune(4)
+				if err != nil {
+					return "", err
+				}
+				r = (r-0xD800)<<10 + (low - 0xDC00) + 0x10000
+			}
+			b.WriteRune(r)
+		default:
+			return "", p.errorf("invalid escape character %q", esc)
+		}
+	}
+}
+
+func (p *jsonParser) parseHexRune(digits int) (rune, error) {
+	if p.pos+digits > len(p.data) {
+		return 0, p.errorf("invalid escape sequence")
+	}
+	v, err := strconv.ParseUint(string(p.data[p.pos:p.pos+digits]), 16, 32)
+	if err != nil {
+		return 0, p.errorf("invalid escape sequence")
+	}
+	for i := 0; i < digits; i++ {
+		p.next()
+	}
+	return rune(v), nil
+}
+
+func utf16IsHighSurrogate(r rune) bool {
+	return r >= 0xD800 && r < 0xDC00
+}
+
+func (p *jsonParser) parseLiteral(n *Node) error {
+	start := p.pos
+	line, column := p.line, p.column
+	for !p.eof() && (isJSONIdentifierRune(p.peek()) || strings.ContainsRune("+-.", p.peek())) {
+		p.next()
+	}
+	literal := string(p.data[start:p.pos])
+	if literal == "" {
+		return p.errorf("unexpected %q", p.peek())
+	}
+
+	n.kind = yaml.ScalarNode
+	switch literal {
+	case "true", "false":
+		n.tag = "!!bool"
+		n.value = literal
+		return nil
+	case "null":
+		n.tag = "!!null"
+		n.value = literal
+		return nil
+	}
+
+	value, tag, ok := normalizeJSONNumber(literal)
+	if !ok {
+		return fmt.Errorf("%d:%d: invalid literal %q", line, column, literal)
+	}
+	n.tag = tag
+	n.value = value
+	return nil
+}
+
+// normalizeJSONNumber converts a JSON(5) number into its YAML representation.
+func normalizeJSONNumber(literal string) (value, tag string, ok bool) {
+	sign := ""
+	unsigned := literal
+	if strings.HasPrefix(unsigned, "-") || strings.HasPrefix(unsigned, "+") {
+		sign = unsigned[:1]
+		unsigned = unsigned[1:]
+	}
+	if sign == "+" {
+		sign = ""
+	}
+
+	switch {
+	case unsigned == "Infinity":
+		return sign + ".inf", "!!float", true
+	case unsigned == "NaN":
+		return ".nan", "!!float", true
+	case strings.HasPrefix(unsigned, "0x") || strings.HasPrefix(unsigned, "0X"):
+		v, err := strconv.ParseUint(unsigned[2:], 16, 64)
+		if err != nil {
+			return "", "", false
+		}
+		return sign + strconv.FormatUint(v, 10), "!!int", true
+	}
+
+	if unsigned == "" || (unsigned[0] != '.' && (unsigned[0] < '0' || unsigned[0] > '9')) {
+		return "", "", false
+	}
+	if _, err := strconv.ParseInt(unsigned, 10, 64); err == nil {
+		return sign + unsigned, "!!int", true
+	}
+	if _, err := strconv.ParseFloat(unsigned, 64); err == nil {
+		return sign + unsigned, "!!float", true
+	}
+	return "", "", false
+}
+
+func isJSONIdentifierRune(r rune) bool {
+	return r == '_' || r == '$' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// encodeJSON writes an exported node tree as indented JSON, keeping the order of the keys.
+func encodeJSON(w io.Writer, node *Node) error {
+	var buf bytes.Buffer
+	if err := appendJSON(&buf, node, ""); err != nil {
+		return err
+	}
+	buf.WriteByte('\n')
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func appendJSON(buf *bytes.Buffer, node *Node, indent string) error {
+	switch node.kind {
+	case yaml.MappingNode:
+		keys := node.MappingKeys()
+		if len(keys) == 0 {
+			buf.WriteString("{}")
+			return nil
+		}
+		buf.WriteString("{\n")
+		for i, key := range keys {
+			buf.WriteString(indent + "  ")
+			if err := appendJSONValue(buf, key); err != nil {
+				return err
+			}
+			buf.WriteString(": ")
+			if err := appendJSON(buf, node.mappingNodes[key], indent+"  "); err != nil {
+				return err
+			}
+			if i < len(keys)-1 {
+				buf.WriteByte(',')
+			}
+			buf.WriteByte('\n')
+		}
+		buf.WriteString(indent + "}")
+	case yaml.SequenceNode:
+		if len(node.sequenceNodes) == 0 {
+			buf.WriteString("[]")
+			return nil
+		}
+		buf.WriteString("[\n")
+		for i, child := range node.sequenceNodes {
+			buf.WriteString(indent + "  ")
+			if err := appendJSON(buf, child, indent+"  "); err != nil {
+				return err
+			}
+			if i < len(node.sequenceNodes)-1 {
+				buf.WriteByte(',')
+			}
+			buf.WriteByte('\n')
+		}
+		buf.WriteString(indent + "]")
+	case yaml.ScalarNode:
+		v, err := scalarValue(node)
+		if err != nil {
+			return err
+		}
+		if err := appendJSONValue(buf, v); err != nil {
+			return fmt.Errorf("%s: %w", node.Keypath(), err)
+		}
+	}
+	return nil
+}
+
+func appendJSONValue(buf *bytes.Buffer, v any) error {
+	var value bytes.Buffer
+	encoder := json.NewEncoder(&value)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return err
+	}
+	buf.Write(bytes.TrimSuffix(value.Bytes(), []byte("\n")))
+	return nil
+}
@@ -0,0 +1,132 @@
+package gofigure
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v3"
+)
+
+var _ = Describe("JSON", func() {
+	It("should decode json", func() {
+		node, err := decodeJSON([]byte(`{
+  "name": "John",
+  "age": 30,
+  "ratio": 1.5e3,
+  "admin": false,
+  "manager": null,
+  "tags": ["a", "b"],
+  "address": {"city": "Paris"}
+}`), NodeFilepath("user.json"))
+		Expect(err).To(BeNil())
+		Expect(node.kind).To(Equal(yaml.MappingNode))
+		Expect(node.Filepath()).To(Equal("user.json"))
+
+		var s struct {
+			Name    string   `yaml:"name"`
+			Age     int      `yaml:"age"`
+			Ratio   float64  `yaml:"ratio"`
+			Admin   bool     `yaml:"admin"`
+			Manager *string  `yaml:"manager"`
+			Tags    []string `yaml:"tags"`
+			Address struct {
+				City string `yaml:"city"`
+			} `yaml:"address"`
+		}
+		Expect(node.ToYAMLNode().Decode(&s)).To(BeNil())
+		Expect(s.Name).To(Equal("John"))
+		Expect(s.Age).To(Equal(30))
+		Expect(s.Ratio).To(Equal(1500.0))
+		Expect(s.Admin).To(BeFalse())
+		Expect(s.Manager).To(BeNil())
+		Expect(s.Tags).To(Equal([]string{"a", "b"}))
+		Expect(s.Address.City).To(Equal("Paris"))
+	})
+
+	It("should keep line and column", func() {
+		node, err := decodeJSON([]byte(`{
+  "name": "John",
+  "tags": [
+    "a",   "b"
+  ]
+}`))
+		Expect(err).To(BeNil())
+		Expect(node.line).To(Equal(1))
+		Expect(node.column).To(Equal(1))
+
+		name, err := node.GetDeep("name")
+		Expect(err).To(BeNil())
+		Expect(name.Line()).To(Equal(2))
+		Expect(name.Column()).To(Equal(11))
+		Expect(name.Keypath()).To(Equal("name"))
+
+		tag, err := node.GetDeep("tags[1]")
+		Expect(err).To(BeNil())
+		Expect(tag.Line()).To(Equal(4))
+		Expect(tag.Column()).To(Equal(12))
+		Expect(tag.Keypath()).To(Equal("tags[1]"))
+	})
+
+	It("should decode jsonc and json5", func() {
+		node, err := decodeJSON([]byte(`// leading comment
+{
+  /* block
+     comment */
+  unquoted: 'single \'quoted\'',
+  hex: 0x1F,
+  positive: +1,
+  fraction: .5,
+  infinity: -Infinity,
+  "escaped": "é\n",
+  list: [1, 2,], // trailing comma
+}`))
+		Expect(err).To(BeNil())
+
+		var s struct {
+			Unquoted string        `yaml:"unquoted"`
+			Hex      int           `yaml:"hex"`
+			Positive int           `yaml:"positive"`
+			Fraction float64       `yaml:"fraction"`
+			Infinity float64       `yaml:"infinity"`
+			Escaped  string        `yaml:"escaped"`
+			List     []interface{} `yaml:"list"`
+		}
+		Expect(node.ToYAMLNode().Decode(&s)).To(BeNil())
+		Expect(s.Unquoted).To(Equal("single 'quoted'"))
+		Expect(s.Hex).To(Equal(31))
+		Expect(s.Positive).To(Equal(1))
+		Expect(s.Fraction).To(Equal(0.5))
+		Expect(s.Infinity).To(BeNumerically("<", 0))
+		Expect(s.Escaped).To(Equal("é\n"))
+		Expect(s.List).To(HaveLen(2))
+	})
+
+	It("should keep strings that look like other types as strings", func() {
+		node, err := decodeJSON([]byte(`{"port": "8080", "enabled": "true"}`))
+		Expect(err).To(BeNil())
+
+		var s map[string]interface{}
+		Expect(node.ToYAMLNode().Decode(&s)).To(BeNil())
+		Expect(s["port"]).To(Equal("8080"))
+		Expect(s["enabled"]).To(Equal("true"))
+	})
+
+	It("should decode empty contents as an empty mapping", func() {
+		node, err := decodeJSON([]byte(" // nothing\n"))
+		Expect(err).To(BeNil())
+		Expect(node.kind).To(Equal(yaml.MappingNode))
+		Expect(node.mappingNodes).To(BeEmpty())
+	})
+})
+
+var _ = DescribeTable("JSON failed scenarios", func(source, message string) {
+	_, err := decodeJSON([]byte(source))
+	Expect(err).To(MatchError(message))
+},
+	Entry("unterminated object", `{"a": 1`, "1:8: unterminated object"),
+	Entry("unterminated array", `[1, 2`, "1:6: unterminated array"),
+	Entry("unterminated string", `{"a": "b}`, "1:10: unterminated string"),
+	Entry("unterminated comment", `/* {}`, "1:1: unterminated comment"),
+	Entry("missing colon", "{\n  \"a\" 1}", "2:7: expected ':' after object key \"a\""),
+	Entry("invalid literal", `{"a": nope}`, "1:7: invalid literal \"nope\""),
+	Entry("trailing value", `{} {}`, "1:4: unexpected '{' after top-level value"),
+)
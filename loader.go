@@ -5,68 +5,382 @@ import (
 	"errors"
 	"fmt"
 	"path/filepath"
+	"reflect"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 
 	"gopkg.in/yaml.v3"
 )
 
+// Loader is safe for concurrent use. Reads go against a snapshot of the config that is fully resolved before it is
+// swapped in, and never changes afterwards.
 type Loader struct {
-	features []Feature
+	features    []Feature
+	decoders    map[string]Decoder
+	decodeHooks map[reflect.Type]DecodeHook
+	// path patterns of the values that are sensitive
+	sensitivePaths []string
+	flagSets       []*boundFlagSet
+	// anchors defined by the YAML files loaded so far, which the files loaded after can use
+	anchors map[string]*Node
+	// nullAsUnset makes null values remove the values they override, see WithNullAsUnset
+	nullAsUnset bool
+	// default strategies of the sequences, see WithMergeStrategy
+	mergeStrategies []pathMergeStrategy
 
+	// mu serializes everything but reads from the snapshot
+	mu sync.Mutex
+
+	// everything loaded so far in order, loaded again into an empty Loader to reload
+	sources []*source
+	// merged tree of the sources, it is never resolved
 	root *Node
+	// set if the sources failed to be merged again after an option of the merge changed, until a Reload succeeds
+	mergeErr error
+
+	// snapshot is a view over a copy of root with the flags merged in, fully resolved, it is dropped once root has
+	// changed.
+	snapshot atomic.Pointer[Loader]
+
+	// set on views, along with the files loaded while resolving, so features can load a file more than once, and the
+	// flag values merged into the view
+	isView           bool
+	loaded           map[string]bool
+	flagsFingerprint string
+
+	// the resolutions of the features in the snapshot, and on views, the ones of the previous snapshot to reuse and
+	// the ones in progress
+	resolutions         map[string]*resolution
+	previousResolutions map[string]*resolution
+	resolving           []*resolution
+
+	reloadFuncs []ReloadFunc
+	subscribers []*subscriber
 }
 
 func New() *Loader {
-	return &Loader{}
+	return (&Loader{}).WithDecodeHooks(defaultDecodeHooks()...)
 }
 
 func (l *Loader) WithFeatures(features ...Feature) *Loader {
-	l.features = append(l.features, features...)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.features = append(l.features[:len(l.features):len(l.features)], features...)
+	l.snapshot.Store(nil)
+	return l
+}
+
+// WithDecoders registers decoders for the formats they handle, replacing any decoder previously registered for the
+// same format.
+func (l *Loader) WithDecoders(decoders ...Decoder) *Loader {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	// views share the registry, so it is replaced instead of modified
+	current := l.registeredDecoders()
+	registry := make(map[string]Decoder, len(current))
+	for format, decoder := range current {
+		registry[format] = decoder
+	}
+	for _, decoder := range decoders {
+		for _, format := range decoder.Formats() {
+			registry[normalizeFormat(format)] = decoder
+		}
+	}
+	l.decoders = registry
+	return l
+}
+
+// WithDecodeHooks registers hooks decoding scalars into the types they handle, replacing any hook previously
+// registered for the same type. Hooks apply to Get and everything built on it.
+func (l *Loader) WithDecodeHooks(hooks ...DecodeHook) *Loader {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	// views share the registry, so it is replaced instead of modified
+	registry := make(map[reflect.Type]DecodeHook, len(l.decodeHooks)+len(hooks))
+	for t, hook := range l.decodeHooks {
+		registry[t] = hook
+	}
+	for _, hook := range hooks {
+		registry[hook.Type()] = hook
+	}
+	l.decodeHooks = registry
+	return l
+}
+
+// WithSensitivePaths marks the values at the paths matching any of patterns as sensitive, along with every value under
+// them, see Node.Sensitive. Patterns are dot paths in which * matches any single key or index and ** any number of
+// them, e.g. **.password.
+func (l *Loader) WithSensitivePaths(patterns ...string) *Loader {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sensitivePaths = append(l.sensitivePaths[:len(l.sensitivePaths):len(l.sensitivePaths)], patterns...)
+	l.snapshot.Store(nil)
+	return l
+}
+
+// WithNullAsUnset makes an explicit null, e.g. "~", in a file or source loaded after another remove the value it
+// overrides, like !delete does, instead of setting it to null. Everything loaded before is merged again; if that fails,
+// e.g. a file has been removed since, reading the config fails with the error, which is reported to the functions
+// registered by OnReload, until a Reload succeeds.
+func (l *Loader) WithNullAsUnset() *Loader {
+	l.setMergeOption(func() {
+		l.nullAsUnset = true
+	})
+	return l
+}
+
+// WithMergeStrategy sets how the sequences at the paths matching pattern, see MatchPath, are merged on the ones they
+// override, unless they are tagged with a merge directive. The last strategy set for a matching pattern applies.
+// Everything loaded before is merged again, as by WithNullAsUnset.
+func (l *Loader) WithMergeStrategy(pattern string, strategy MergeStrategy) *Loader {
+	l.setMergeOption(func() {
+		l.mergeStrategies = append(l.mergeStrategies[:len(l.mergeStrategies):len(l.mergeStrategies)],
+			pathMergeStrategy{pattern: pattern, strategy: strategy})
+	})
 	return l
 }
 
+func (l *Loader) decoder(format string) Decoder {
+	return l.registeredDecoders()[normalizeFormat(format)]
+}
+
+// registeredDecoders returns the decoder registry, which is the built-in YAML, JSON and TOML decoders until
+// WithDecoders is called, so the zero Loader loads them too.
+func (l *Loader) registeredDecoders() map[string]Decoder {
+	if l.decoders != nil {
+		return l.decoders
+	}
+	return builtinDecoders
+}
+
+// Load loads a file, the format is detected from its extension. Files with an extension no decoder is registered for
+// are loaded as YAML.
 func (l *Loader) Load(name string, contents []byte) error {
+	format := filepath.Ext(filepath.Clean(name))
+	if l.decoder(format) == nil {
+		format = ".yaml"
+	}
+	return l.LoadAs(name, format, contents)
+}
+
+// LoadAs loads a file with the decoder registered for format, which is either a file extension or a MIME type.
+func (l *Loader) LoadAs(name, format string, contents []byte) error {
 	name = filepath.Clean(name)
-	name = strings.TrimSuffix(name, filepath.Ext(name))
+	keypath := strings.TrimSuffix(name, filepath.Ext(name))
+	if keypath == "" {
+		name = ""
+	}
+	return l.addSource(func(into *Loader) error {
+		return into.load(name, keypath, format, contents)
+	}, nil)
+}
+
+// source is something loaded, along with how to tell whether it has changed since without loading it again.
+type source struct {
+	load func(into *Loader) error
+	// stamp summarizes what the source reads, e.g. the sizes and modification times of its files, it is nil for
+	// sources that never change
+	stamp func() (string, error)
+	// the stamp of what was loaded last
+	stamped string
+}
+
+// stampSource returns the stamp of a source, which is empty if it cannot be stamped.
+func stampSource(stamp func() (string, error)) string {
+	if stamp == nil {
+		return ""
+	}
+	stamped, err := stamp()
+	if err != nil {
+		return ""
+	}
+	return stamped
+}
+
+// addSource loads a source, and records it to be loaded again on reload, unless l is a view, so the files loaded by
+// features while resolving are loaded again when resolving the reloaded config instead.
+func (l *Loader) addSource(load func(into *Loader) error, stamp func() (string, error)) error {
+	if l.isView {
+		return load(l)
+	}
+
+	// stamped before loading, so the changes made while loading are picked up by the next poll
+	stamped := stampSource(stamp)
+
+	l.mu.Lock()
+	var before *Loader
+	if len(l.subscribers) > 0 {
+		before, _ = l.resolver(context.Background())
+	}
+
+	if err := load(l); err != nil {
+		l.mu.Unlock()
+		return err
+	}
+	l.sources = append(l.sources, &source{load: load, stamp: stamp, stamped: stamped})
+
+	var notifications []func()
+	if len(l.subscribers) > 0 {
+		if after, err := l.resolver(context.Background()); err == nil {
+			notifications = l.changes(before, after)
+		}
+	}
+	l.mu.Unlock()
+
+	notify(notifications)
+	return nil
+}
+
+// load decodes the file name and merges it nested under keypath, which is separated by filepath.Separator.
+func (l *Loader) load(name, keypath, format string, contents []byte) error {
+	if l.isView {
+		if l.loaded[name] {
+			return nil
+		}
+		l.loaded[name] = true
+	}
+
+	decoder := l.decoder(format)
+	if decoder == nil {
+		return fmt.Errorf("unable to load file %q as %q: %w", name, format, ErrUnsupportedFormat)
+	}
 
-	var yamlNode yaml.Node
-	if err := yaml.Unmarshal(contents, &yamlNode); err != nil {
+	fileNode, err := decoder.Decode(contents, NodeFilepath(name), NodeAnchors(l.anchors))
+	if err != nil {
 		return fmt.Errorf("unable to unmarshal file %q: %w", name, errors.Join(err, ErrConfigParseError))
 	}
+	l.anchors = collectAnchors(l.anchors, fileNode)
 
-	// root node is a document node, and the first child is a map holds all the values
-	fileNode := NewNode(yamlNode.Content[0], NodeFilepath(name))
-	if name != "" {
-		names := strings.Split(name, string(filepath.Separator))
+	if keypath != "" {
+		names := strings.Split(keypath, string(filepath.Separator))
 		// nest the file with its path, e.g, config/app.yaml -> config.app
 		fileNode = PackNodeInNestedKeys(fileNode, names...)
 	}
 
+	if err := l.merge(fileNode); err != nil {
+		return fmt.Errorf("unable to merge file %q: %w", name, errors.Join(err, ErrConfigParseError))
+	}
+	return nil
+}
+
+// merge merges node on top of root.
+func (l *Loader) merge(node *Node) error {
+	m := &merger{nullAsUnset: l.nullAsUnset, strategies: l.mergeStrategies}
+	var err error
 	if l.root == nil {
-		l.root = fileNode
+		node, err = m.clearDirectives(node)
 	} else {
-		newNode, err := mergeToNode(l.root, fileNode)
-		if err != nil {
-			return fmt.Errorf("unable to merge file %q: %w", name, errors.Join(err, ErrConfigParseError))
+		node, err = m.merge(l.root, node)
+	}
+	if err != nil {
+		return err
+	}
+	// the nodes merged under a sensitive node are sensitive too
+	markSensitive(node, false)
+	l.root = node
+	l.snapshot.Store(nil)
+	return nil
+}
+
+// resolver returns the snapshot, which is created again if root or the flags have changed since.
+func (l *Loader) resolver(ctx context.Context) (*Loader, error) {
+	flagsNode, fingerprint, err := l.flagsNode()
+	if err != nil {
+		return nil, err
+	}
+	if snapshot := l.snapshot.Load(); snapshot != nil && fingerprint == snapshot.flagsFingerprint {
+		return snapshot, nil
+	}
+
+	view := &Loader{
+		features:            l.features,
+		decoders:            l.decoders,
+		decodeHooks:         l.decodeHooks,
+		sensitivePaths:      l.sensitivePaths,
+		flagSets:            l.flagSets,
+		anchors:             l.anchors,
+		nullAsUnset:         l.nullAsUnset,
+		mergeStrategies:     l.mergeStrategies,
+		root:                l.root.clone(),
+		isView:              true,
+		loaded:              map[string]bool{},
+		flagsFingerprint:    fingerprint,
+		resolutions:         map[string]*resolution{},
+		previousResolutions: l.resolutions,
+	}
+	if len(flagsNode.mappingNodes) > 0 {
+		if err := view.merge(flagsNode); err != nil {
+			return nil, fmt.Errorf("unable to merge flags: %w", errors.Join(err, ErrConfigParseError))
 		}
-		l.root = newNode
 	}
+	view.resolveAll(ctx)
 
-	return nil
+	l.resolutions = view.resolutions
+	// the config is not read from a tree that failed to be merged again
+	if l.mergeErr == nil {
+		l.snapshot.Store(view)
+	}
+	return view, nil
 }
 
+// Get decodes the resolved value at path into target, applying the default and validate struct tags of its fields,
+// see DecodeError. target is left untouched if there is no value at path, except for defaults.
 func (l *Loader) Get(ctx context.Context, path string, target any) error {
 	node, err := l.GetNode(ctx, path)
 	if err != nil {
 		return err
 	}
-	if node == nil {
-		return nil
+	if node != nil {
+		return decode(l.decodeHooks, node, nil, path, target)
+	}
+
+	// missing values are reported at the closest mapping there is
+	var position *Node
+	for parent := path; parent != "" && position == nil; {
+		parent = parent[:max(strings.LastIndexAny(parent, ".["), 0)]
+		if position, err = l.GetNode(ctx, parent); err != nil {
+			return err
+		}
 	}
-	return node.ToYAMLNode().Decode(target)
+	return decode(l.decodeHooks, nil, position, path, target)
 }
 
+// GetNode returns the resolved value at path, or nil if there is none. The node must not be modified.
 func (l *Loader) GetNode(ctx context.Context, path string) (*Node, error) {
+	if l.isView {
+		node, err := l.getNode(ctx, path)
+		if n := len(l.resolving); n > 0 {
+			l.resolving[n-1].depend(path, node, err)
+		}
+		return node, err
+	}
+
+	snapshot, err := l.current(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return snapshot.lookup(path)
+}
+
+// current returns the snapshot, which is created if there is none. The flags are only read when it is created, as they
+// may be parsed concurrently.
+func (l *Loader) current(ctx context.Context) (*Loader, error) {
+	if snapshot := l.snapshot.Load(); snapshot != nil {
+		return snapshot, nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.mergeErr != nil {
+		return nil, l.mergeErr
+	}
+	return l.resolver(ctx)
+}
+
+// getNode resolves the value at path in a view.
+func (l *Loader) getNode(ctx context.Context, path string) (*Node, error) {
 	current := l.root
 	if len(path) > 0 {
 		paths, err := ParseDotPath(path)
@@ -99,57 +413,126 @@ func (l *Loader) GetNode(ctx context.Context, path string) (*Node, error) {
 			if err != nil {
 				return nil, err
 			}
+		}
+	}
+
+	if current == nil {
+		return nil, nil
+	}
+
+	current, err := l.resolve(ctx, current)
+	if err != nil {
+		return nil, err
+	}
+	if err := resolveError(current); err != nil {
+		return nil, err
+	}
+	return current, nil
+}
+
+// lookup returns the value at path in a view that has been fully resolved, without resolving anything, so it is safe
+// for concurrent use.
+func (l *Loader) lookup(path string) (*Node, error) {
+	node, err := l.lookupRaw(path)
+	if err != nil || node == nil {
+		return nil, err
+	}
+	if err := resolveError(node); err != nil {
+		return nil, err
+	}
+	return resolvedValue(node), nil
+}
+
+// lookupRaw returns the node at path in a view that has been fully resolved, before it is resolved itself.
+func (l *Loader) lookupRaw(path string) (*Node, error) {
+	current := l.root
+	if len(path) > 0 {
+		paths, err := ParseDotPath(path)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse path %q: %w", path, err)
+		}
 
+		for _, p := range paths {
+			current = resolvedValue(current)
 			if current == nil {
 				break
 			}
+			if current.resolveErr != nil {
+				return nil, current.resolveErr
+			}
+			if p.Key != "" { // map
+				current, err = current.GetMappingChild(p.Key)
+			} else { // slice
+				current, err = current.GetSequenceChild(p.Index)
+			}
+			if err != nil {
+				return nil, err
+			}
 		}
 	}
+	return current, nil
+}
 
-	if current == nil {
-		return nil, nil
+// resolveAll resolves every node of a view, the errors are kept on the nodes that failed to resolve. Files loaded by
+// features while resolving are resolved as well.
+func (l *Loader) resolveAll(ctx context.Context) {
+	for l.root != nil {
+		loaded := len(l.loaded)
+		l.resolveTree(ctx, l.root)
+		if len(l.loaded) == loaded {
+			return
+		}
 	}
+}
 
-	return l.resolve(ctx, current)
+func (l *Loader) resolveTree(ctx context.Context, node *Node) {
+	_, _ = l.resolve(ctx, node)
+	for _, key := range sortedKeys(node.mappingNodes) {
+		l.resolveTree(ctx, node.mappingNodes[key])
+	}
+	for _, child := range node.sequenceNodes {
+		l.resolveTree(ctx, child)
+	}
 }
 
-func (l *Loader) resolve(ctx context.Context, node *Node) (resultNode *Node, reterr error) {
+// resolve resolves a node and the nodes under it, and returns its resolved value. A node failing to resolve keeps the
+// error, which is returned every time it is resolved again, and does not prevent the other nodes from being resolved.
+func (l *Loader) resolve(ctx context.Context, node *Node) (*Node, error) {
 	if node.resolved {
-		// if the node is resolved by tagged resolver, the result is stored in resolvedNode (so the original value can be preserved)
-		if node.resolvedNode != nil {
-			return node.resolvedNode, nil
+		if node.resolving {
+			return nil, newNodeError(node, ErrCircularReference)
 		}
-		return node, nil
+		if node.resolveErr != nil {
+			return nil, node.resolveErr
+		}
+		// if the node is resolved by tagged resolver, the result is stored in resolvedNode (so the original value can be preserved)
+		return resolvedValue(node), nil
 	}
 
 	// set it to true first to avoid infinite loop
 	node.resolved = true
-	defer func() {
-		if reterr != nil {
-			node.resolved = false
-		}
-	}()
+	if l.isSensitivePath(node) {
+		markSensitive(node, true)
+	}
 
 	// resolve children first for mapping and sequence nodes
-	if node.kind == yaml.MappingNode {
-		for key := range node.mappingNodes {
-			childNode, err := l.resolve(ctx, node.mappingNodes[key])
-			if err != nil {
-				return nil, err
-			}
-			node.mappingNodes[key] = childNode
+	var childErr error
+	for _, key := range sortedKeys(node.mappingNodes) {
+		if _, err := l.resolve(ctx, node.mappingNodes[key]); err != nil && childErr == nil {
+			childErr = err
 		}
 	}
-
-	if node.kind == yaml.SequenceNode {
-		for i := range node.sequenceNodes {
-			childNode, err := l.resolve(ctx, node.sequenceNodes[i])
-			if err != nil {
-				return nil, err
-			}
-			node.sequenceNodes[i] = childNode
+	for _, child := range node.sequenceNodes {
+		if _, err := l.resolve(ctx, child); err != nil && childErr == nil {
+			childErr = err
 		}
 	}
+	if childErr != nil {
+		if node.style&yaml.TaggedStyle != 0 {
+			node.resolveErr = childErr
+		}
+		return nil, childErr
+	}
 
 	// if not tagged, no further action is needed
 	if node.style&yaml.TaggedStyle == 0 {
@@ -159,15 +542,105 @@ func (l *Loader) resolve(ctx context.Context, node *Node) (resultNode *Node, ret
 	// resolve the node with the feature if matched
 	for _, feature := range l.features {
 		if feature.Name() == node.tag {
-			result, err := feature.Resolve(ctx, l, node)
+			node.resolving = true
+			result, err := l.resolveFeature(ctx, feature, node)
+			node.resolving = false
 			if err != nil {
-				node.resolved = false
-				return nil, newNodeError(node, err)
+				node.resolveErr = newNodeError(node, err)
+				return nil, node.resolveErr
 			}
 			node.resolvedNode = result
-			return node, nil
+			return resolvedValue(node), nil
 		}
 	}
 
 	return node, nil
 }
+
+// resolveError returns the first error of the nodes that failed to resolve under node, including the values they are
+// resolved to.
+func resolveError(node *Node) error {
+	for node != nil {
+		if node.resolving {
+			return newNodeError(node, ErrCircularReference)
+		}
+		if node.resolveErr != nil {
+			return node.resolveErr
+		}
+		if !node.resolved || node.resolvedNode == nil {
+			break
+		}
+		node = node.resolvedNode
+	}
+	if node == nil {
+		return nil
+	}
+
+	for _, key := range sortedKeys(node.mappingNodes) {
+		if err := resolveError(node.mappingNodes[key]); err != nil {
+			return err
+		}
+	}
+	for _, child := range node.sequenceNodes {
+		if err := resolveError(child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Validate resolves every value, and returns the errors of all the values that fail to resolve, joined.
+func (l *Loader) Validate(ctx context.Context) error {
+	snapshot, err := l.current(ctx)
+	if err != nil {
+		return err
+	}
+	if snapshot.root == nil {
+		return nil
+	}
+
+	var errs []error
+	resolveErrors(snapshot.root, &errs)
+	return errors.Join(errs...)
+}
+
+// resolveErrors appends the errors of the nodes that failed to resolve under node, each error once.
+func resolveErrors(node *Node, errs *[]error) {
+	if node == nil {
+		return
+	}
+	if err := resolveError(node); err == nil {
+		return
+	}
+
+	err := node.resolveErr
+	if node.resolving {
+		err = newNodeError(node, ErrCircularReference)
+	}
+	if err != nil {
+		for _, e := range *errs {
+			if e == err {
+				return
+			}
+		}
+		*errs = append(*errs, err)
+		return
+	}
+
+	node = resolvedValue(node)
+	for _, key := range node.MappingKeys() {
+		resolveErrors(node.mappingNodes[key], errs)
+	}
+	for _, child := range node.sequenceNodes {
+		resolveErrors(child, errs)
+	}
+}
+
+func sortedKeys(m map[string]*Node) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
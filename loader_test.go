@@ -2,6 +2,9 @@ package gofigure
 
 import (
 	"context"
+	"fmt"
+	"strings"
+	"sync"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -26,6 +29,20 @@ name: John`))).To(BeNil())
 		Expect(loader.root.mappingNodes["another"].mappingNodes["value"].value).To(Equal("another"))
 	})
 
+	It("should Load with the zero Loader", func() {
+		var loader Loader
+		Expect(loader.Load("app.yaml", []byte(`name: yaml`))).To(BeNil())
+		Expect(loader.Load("db.json", []byte(`{"name": "json"}`))).To(BeNil())
+		Expect(loader.Load("cache.toml", []byte(`name = "toml"`))).To(BeNil())
+		for path, expected := range map[string]string{"app.name": "yaml", "db.name": "json", "cache.name": "toml"} {
+			var value string
+			Expect(loader.Get(context.Background(), path, &value)).To(BeNil())
+			Expect(value).To(Equal(expected))
+		}
+
+		Expect((&Loader{}).WithDecoders(DecoderFunc(decodeYAML, ".conf")).Load("app.json", []byte(`{}`))).To(BeNil())
+	})
+
 	It("should Get", func() {
 		loader := New()
 		Expect(loader.Load("config/app.yaml", []byte(`array:
@@ -70,4 +87,150 @@ name:
 		Expect(loader.root.mappingNodes["name"].mappingNodes["first"].value).To(Equal("Jane"))
 		Expect(loader.root.mappingNodes["name"].mappingNodes["last"].value).To(Equal("Doe"))
 	})
+
+	It("should Load json and merge with yaml", func() {
+		loader := New()
+		Expect(loader.Load("config/app.yaml", []byte(`env: dev
+name:
+  first: John
+  last: Doe`))).To(BeNil())
+		Expect(loader.Load("config/app.jsonc", []byte(`{
+  // overrides from json
+  "env": "prod",
+  "name": {"first": "Jane",},
+}`))).To(BeNil())
+
+		appNode := loader.root.mappingNodes["config"].mappingNodes["app"]
+		Expect(appNode.mappingNodes["env"].value).To(Equal("prod"))
+		Expect(appNode.mappingNodes["env"].Filepath()).To(Equal("config/app.jsonc"))
+		Expect(appNode.mappingNodes["env"].Line()).To(Equal(3))
+		Expect(appNode.mappingNodes["env"].Column()).To(Equal(10))
+		Expect(appNode.mappingNodes["name"].mappingNodes["first"].value).To(Equal("Jane"))
+		Expect(appNode.mappingNodes["name"].mappingNodes["last"].value).To(Equal("Doe"))
+		Expect(appNode.mappingNodes["name"].mappingNodes["last"].Filepath()).To(Equal("config/app.yaml"))
+	})
+
+	It("should report the file when unable to Load", func() {
+		loader := New()
+		err := loader.Load("config/app.json", []byte(`{"env": }`))
+		Expect(err).To(MatchError(ErrConfigParseError))
+		Expect(err.Error()).To(ContainSubstring(`unable to unmarshal file "config/app.json"`))
+	})
+
+	It("should keep resolving the other values when one fails", func() {
+		loader := New().WithFeatures(refFeature)
+		Expect(loader.Load("app.yaml", []byte(`port: 8080
+listen: !ref app.port
+broken: !ref app.missing.port
+self: !ref app.self
+nested:
+  loop: !ref app.nested`))).To(BeNil())
+
+		var port int
+		Expect(loader.Get(context.Background(), "app.listen", &port)).To(BeNil())
+		Expect(port).To(Equal(8080))
+
+		_, err := loader.GetNode(context.Background(), "app.broken")
+		Expect(err).To(MatchError(ErrPathNotFound))
+		_, err = loader.GetNode(context.Background(), "app")
+		Expect(err).To(MatchError(ErrPathNotFound))
+		_, err = loader.GetNode(context.Background(), "app.self")
+		Expect(err).To(MatchError(ErrCircularReference))
+		_, err = loader.GetNode(context.Background(), "app.nested.loop")
+		Expect(err).To(MatchError(ErrCircularReference))
+	})
+
+	It("should be safe for concurrent use", func() {
+		loader := New().WithFeatures(refFeature)
+		Expect(loader.Load("app.yaml", []byte(`port: 8080
+listen: !ref app.port`))).To(BeNil())
+		loader.OnChange("app.listen", func(old, new *Node) {})
+
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer GinkgoRecover()
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					var port int
+					Expect(loader.Get(context.Background(), "app.listen", &port)).To(BeNil())
+					Expect(port).To(BeNumerically(">=", 8080))
+					node, err := loader.GetNode(context.Background(), "app")
+					Expect(err).To(BeNil())
+					Expect(node.ToYAMLNode().Content).To(HaveLen(4))
+				}
+			}()
+		}
+		for i := 0; i < 2; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer GinkgoRecover()
+				defer wg.Done()
+				for j := 0; j < 50; j++ {
+					Expect(loader.Load("app.yaml", []byte(fmt.Sprintf(`port: %d`, 8080+i*100+j)))).To(BeNil())
+					_, err := loader.Reload(context.Background())
+					Expect(err).To(BeNil())
+				}
+			}(i)
+		}
+		wg.Wait()
+	})
+
+	It("should keep key order and comments", func() {
+		loader := New().WithFeatures(refFeature)
+		Expect(loader.Load("app.yaml", []byte(`# application settings
+
+# the name
+name: gofigure
+# the port to listen on
+port: 8080 # default port
+# foot of port
+
+servers:
+  - host: a
+    port: 1
+listen: !ref app.port
+zone: a
+`))).To(BeNil())
+		Expect(loader.Load("app.json", []byte(`{"port": 80, "zone": "b", "debug": true, "alpha": 1}`))).To(BeNil())
+
+		node, err := loader.GetNode(context.Background(), "app")
+		Expect(err).To(BeNil())
+		expected := `# application settings
+# the name
+name: gofigure
+# the port to listen on
+port: 80
+# foot of port
+
+servers:
+    - host: a
+      port: 1
+listen: 80
+zone: "b"
+debug: true
+alpha: 1
+`
+		for i := 0; i < 10; i++ {
+			b, err := yaml.Marshal(node)
+			Expect(err).To(BeNil())
+			Expect(string(b)).To(Equal(expected))
+		}
+	})
+
+	It("should validate every value", func() {
+		loader := New().WithFeatures(refFeature)
+		Expect(loader.Validate(context.Background())).To(BeNil())
+		Expect(loader.Load("app.yaml", []byte(`port: 8080
+listen: !ref app.port
+missing: !ref app.nothing
+nested:
+  another: !ref app.nowhere
+  copy: !ref app.missing`))).To(BeNil())
+
+		err := loader.Validate(context.Background())
+		Expect(err).To(MatchError(ErrPathNotFound))
+		Expect(strings.Split(err.Error(), "\n")).To(HaveLen(3))
+	})
 })
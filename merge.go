@@ -14,6 +14,9 @@ func PackNodeInNestedKeys(node *Node, keys ...string) *Node {
 			key: result,
 		})
 
+		// the keys are set by the file of the node they wrap
+		childNode.filepath = result.Filepath()
+		childNode.source = result.source
 		result.parent = childNode
 		result.mappingKey = key
 		result.hasMappingKey = true
@@ -22,17 +25,91 @@ func PackNodeInNestedKeys(node *Node, keys ...string) *Node {
 	return result
 }
 
+// The tags of the merge directives, which set how a value is merged on the one it overrides. Values are merged
+// into mappings, and replace scalars and sequences, by default.
+const (
+	// AppendTag appends a sequence to the one it overrides.
+	AppendTag = "!append"
+	// PrependTag prepends a sequence to the one it overrides.
+	PrependTag = "!prepend"
+	// UniqueTag appends a sequence to the one it overrides, and removes the scalars already in it.
+	UniqueTag = "!unique"
+	// MergeTag merges the mappings of a sequence into the ones of the sequence it overrides which have the same
+	// identity, the value of their "name" key, and appends the others. The identity key is set with the mapping form,
+	// !merge {key: id, items: [...]}. Items tagged !delete remove the items they match.
+	MergeTag = "!merge"
+	// ReplaceTag replaces the value it overrides, instead of merging into it.
+	ReplaceTag = "!replace"
+	// DeleteTag and UnsetTag remove the value they override, along with its key.
+	DeleteTag = "!delete"
+	UnsetTag  = "!unset"
+)
+
+// defaultMergeKey is the identity key of the items merged by !merge.
+const defaultMergeKey = "name"
+
+// MergeStrategy is how a sequence without merge directive is merged on the sequence it overrides, see
+// Loader.WithMergeStrategy.
+type MergeStrategy struct {
+	tag string
+	// identity key of the items merged by MergeByKey
+	key string
+}
+
+// MergeReplace replaces the sequence, which is the default.
+func MergeReplace() MergeStrategy {
+	return MergeStrategy{}
+}
+
+// MergeAppend merges sequences as if they were tagged !append.
+func MergeAppend() MergeStrategy {
+	return MergeStrategy{tag: AppendTag}
+}
+
+// MergePrepend merges sequences as if they were tagged !prepend.
+func MergePrepend() MergeStrategy {
+	return MergeStrategy{tag: PrependTag}
+}
+
+// MergeUnique merges sequences as if they were tagged !unique.
+func MergeUnique() MergeStrategy {
+	return MergeStrategy{tag: UniqueTag}
+}
+
+// MergeByKey merges sequences as if they were tagged !merge, with key as the identity key of their items.
+func MergeByKey(key string) MergeStrategy {
+	return MergeStrategy{tag: MergeTag, key: key}
+}
+
+type pathMergeStrategy struct {
+	pattern  string
+	strategy MergeStrategy
+}
+
+// MergeNodes merges nodes on top of each other in order, applying the merge directives.
 func MergeNodes(nodes ...*Node) (*Node, error) {
+	return (&merger{}).mergeNodes(nodes...)
+}
+
+// merger merges nodes with the options of a Loader.
+type merger struct {
+	// nullAsUnset makes a null value remove the value it overrides, see Loader.WithNullAsUnset
+	nullAsUnset bool
+	// strategies of the sequences without merge directive, see Loader.WithMergeStrategy
+	strategies []pathMergeStrategy
+}
+
+func (m *merger) mergeNodes(nodes ...*Node) (*Node, error) {
 	var rootNode *Node
 	for _, node := range nodes {
+		var err error
 		if rootNode == nil {
-			rootNode = node
+			rootNode, err = m.clearDirectives(node)
 		} else {
-			result, err := mergeToNode(rootNode, node)
-			if err != nil {
-				return nil, err
-			}
-			rootNode = result
+			rootNode, err = m.merge(rootNode, node)
+		}
+		if err != nil {
+			return nil, err
 		}
 	}
 
@@ -40,16 +117,40 @@ func MergeNodes(nodes ...*Node) (*Node, error) {
 }
 
 func mergeToNode(n, another *Node) (*Node, error) {
+	return (&merger{}).merge(n, another)
+}
+
+//nolint:gocyclo
+func (m *merger) merge(n, another *Node) (*Node, error) {
 	var err error
 
-	shouldAppend := false
-	if another.style == yaml.TaggedStyle {
-		if another.tag != "!append" {
+	// a value overriding a sensitive value is sensitive too, and the other way around, as the result is either
+	if n.sensitive || another.sensitive {
+		n.sensitive, another.sensitive = true, true
+	}
+
+	var strategy MergeStrategy
+	if another.style&yaml.TaggedStyle != 0 {
+		switch another.tag {
+		case AppendTag, PrependTag, UniqueTag, MergeTag:
+			// the directive is kept in the origins, before the tag is cleared
+			origins := another.Origins()
+			if strategy, another, err = sequenceDirective(another); err != nil {
+				return nil, err
+			}
+			another.origins = origins
+			another.style = n.style
+		case ReplaceTag:
+			another.origins = chainOrigins(n, another)
+			another.style &^= yaml.TaggedStyle
+			another.tag = ""
+			return m.clearDirectives(another)
+		default:
+			another.origins = chainOrigins(n, another)
 			return another, nil
 		}
-		another.style = n.style
-		another.tag = ""
-		shouldAppend = true
+	} else if n.kind == yaml.SequenceNode && another.kind == yaml.SequenceNode && !another.sparse {
+		strategy = m.strategy(n.Keypath())
 	}
 
 	if n.kind != another.kind {
@@ -62,24 +163,290 @@ func mergeToNode(n, another *Node) (*Node, error) {
 	case yaml.DocumentNode:
 		return nil, fmt.Errorf("document node cannot be merged")
 	case yaml.MappingNode:
-		for key, value := range another.mappingNodes {
-			if destNode, ok := n.mappingNodes[key]; ok {
-				n.mappingNodes[key], err = mergeToNode(destNode, value)
+		n.origins = chainOrigins(n, another)
+		for _, key := range another.MappingKeys() {
+			value := another.mappingNodes[key]
+			destNode, ok := n.mappingNodes[key]
+			if isDeletion(value) || ok && m.nullAsUnset && isNull(value) {
+				if ok {
+					n.deleteMappingChild(key)
+					n.origins = append(n.origins, value.origin())
+				}
+				continue
+			}
+			if ok {
+				// the tree is left as it is if the value cannot be merged
+				merged, err := m.merge(destNode, value)
 				if err != nil {
 					return nil, err
 				}
+				n.mappingNodes[key] = merged
+				inheritKeyComments(n.mappingNodes[key], destNode)
+				inheritKeyComments(n.mappingNodes[key], value)
 			} else {
-				n.mappingNodes[key] = value
+				if value, err = m.clearDirectives(value); err != nil {
+					return nil, err
+				}
+				n.setMappingChild(key, value)
 			}
 		}
 	case yaml.SequenceNode:
-		if shouldAppend {
-			n.sequenceNodes = append(n.sequenceNodes, another.sequenceNodes...)
+		if another.sparse {
+			n.origins = chainOrigins(n, another)
+			for _, value := range another.sequenceNodes {
+				switch {
+				case value.sequenceIndex < len(n.sequenceNodes):
+					merged, err := m.merge(n.sequenceNodes[value.sequenceIndex], value)
+					if err != nil {
+						return nil, err
+					}
+					n.sequenceNodes[value.sequenceIndex] = merged
+				case value.sequenceIndex == len(n.sequenceNodes):
+					if value, err = m.clearDirectives(value); err != nil {
+						return nil, err
+					}
+					n.sequenceNodes = append(n.sequenceNodes, value)
+				default:
+					return nil, sparseIndexError(another, value.sequenceIndex, len(n.sequenceNodes))
+				}
+			}
 			return n, nil
 		}
-		return another, nil
+		if strategy.tag == "" {
+			another.origins = chainOrigins(n, another)
+			return m.clearDirectives(another)
+		}
+
+		n.origins = chainOrigins(n, another)
+		items := make([]*Node, 0, len(another.sequenceNodes))
+		for _, item := range another.sequenceNodes {
+			// the items to delete are only matched by !merge
+			if isDeletion(item) {
+				if strategy.tag == MergeTag {
+					items = append(items, item)
+				}
+				continue
+			}
+			if item, err = m.clearDirectives(item); err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		switch strategy.tag {
+		case AppendTag:
+			n.sequenceNodes = append(n.sequenceNodes, items...)
+		case PrependTag:
+			n.sequenceNodes = append(items, n.sequenceNodes...)
+		case UniqueTag:
+			n.sequenceNodes = uniqueScalars(append(n.sequenceNodes, items...))
+		case MergeTag:
+			if err := m.mergeByKey(n, items, strategy.key); err != nil {
+				return nil, err
+			}
+		}
+		for i, item := range n.sequenceNodes {
+			item.sequenceIndex, item.hasSequenceIndex = i, true
+		}
+		return n, nil
 	case yaml.ScalarNode:
+		another.origins = chainOrigins(n, another)
 		return another, nil
 	}
 	return n, nil
 }
+
+// strategy returns the strategy of the sequences at path, the last one set for a pattern matching it.
+func (m *merger) strategy(path string) MergeStrategy {
+	for i := len(m.strategies) - 1; i >= 0; i-- {
+		if MatchPath(m.strategies[i].pattern, path) {
+			return m.strategies[i].strategy
+		}
+	}
+	return MergeStrategy{}
+}
+
+// sequenceDirective returns the strategy of a sequence tagged with a sequence merge directive, and the sequence
+// itself, which is the items of the mapping form of !merge, with its tag cleared.
+func sequenceDirective(node *Node) (MergeStrategy, *Node, error) {
+	strategy := MergeStrategy{tag: node.tag}
+	if node.tag == MergeTag {
+		strategy.key = defaultMergeKey
+	}
+	if node.tag == MergeTag && node.kind == yaml.MappingNode {
+		for _, key := range node.MappingKeys() {
+			if key != "key" && key != "items" {
+				return strategy, nil, newNodeError(node, fmt.Errorf("unknown !merge key %q, expected key or items", key))
+			}
+		}
+		key, items := node.mappingNodes["key"], node.mappingNodes["items"]
+		if key != nil {
+			if key.kind != yaml.ScalarNode || key.value == "" {
+				return strategy, nil, newNodeError(key, fmt.Errorf("!merge key must be a non-empty string"))
+			}
+			strategy.key = key.value
+		}
+		if items == nil || items.kind != yaml.SequenceNode {
+			return strategy, nil, newNodeError(node, fmt.Errorf("!merge expects a sequence of items"))
+		}
+		items.parent = node.parent
+		items.mappingKey, items.hasMappingKey = node.mappingKey, node.hasMappingKey
+		items.sequenceIndex, items.hasSequenceIndex = node.sequenceIndex, node.hasSequenceIndex
+		items.keyHeadComment, items.keyLineComment, items.keyFootComment =
+			node.keyHeadComment, node.keyLineComment, node.keyFootComment
+		items.sensitive = items.sensitive || node.sensitive
+		node = items
+	}
+	if node.kind != yaml.SequenceNode {
+		return strategy, nil, newNodeError(node, fmt.Errorf("%s expects a sequence", strategy.tag))
+	}
+	node.style &^= yaml.TaggedStyle
+	node.tag = ""
+	return strategy, node, nil
+}
+
+// mergeByKey merges items into the items of n with the same identity, the value of their key, and appends the
+// others. Items tagged !delete remove the items they match.
+func (m *merger) mergeByKey(n *Node, items []*Node, key string) error {
+	for _, item := range items {
+		deletion := isDeletion(item)
+		identity, ok := mergeIdentity(item, key)
+		index := -1
+		if ok {
+			for i, base := range n.sequenceNodes {
+				if baseIdentity, ok := mergeIdentity(base, key); ok && baseIdentity == identity {
+					index = i
+					break
+				}
+			}
+		}
+		switch {
+		case deletion && index >= 0:
+			n.sequenceNodes = append(n.sequenceNodes[:index:index], n.sequenceNodes[index+1:]...)
+			n.origins = append(n.origins, item.origin())
+		case deletion:
+		case index >= 0:
+			merged, err := m.merge(n.sequenceNodes[index], item)
+			if err != nil {
+				return err
+			}
+			n.sequenceNodes[index] = merged
+		default:
+			n.sequenceNodes = append(n.sequenceNodes, item)
+		}
+	}
+	return nil
+}
+
+// mergeIdentity returns the value of key of a mapping item merged by !merge.
+func mergeIdentity(item *Node, key string) (string, bool) {
+	if item == nil || item.kind != yaml.MappingNode {
+		return "", false
+	}
+	value := item.mappingNodes[key]
+	if value == nil || value.kind != yaml.ScalarNode {
+		return "", false
+	}
+	return value.value, true
+}
+
+// uniqueScalars removes the scalars of items with the same tag and value as a scalar before them.
+func uniqueScalars(items []*Node) []*Node {
+	seen := make(map[[2]string]bool, len(items))
+	result := items[:0]
+	for _, item := range items {
+		if item != nil && item.kind == yaml.ScalarNode {
+			key := [2]string{item.tag, item.value}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+		}
+		result = append(result, item)
+	}
+	return result
+}
+
+// isDeletion reports whether node is a !delete or !unset directive.
+func isDeletion(node *Node) bool {
+	return node.style&yaml.TaggedStyle != 0 && (node.tag == DeleteTag || node.tag == UnsetTag)
+}
+
+// sparseIndexError is the error of an item of a sparse sequence past the end of the sequence it is merged on, items
+// can only be added right after the last one, so the indices set are the ones read back.
+func sparseIndexError(sparse *Node, index, length int) error {
+	return fmt.Errorf("%q: index %d is past the end of the sequence, which has %d items", sparse.Keypath(), index, length)
+}
+
+// clearDirectives clears the merge directives under node, which has no value to be merged on: the keys to delete are
+// removed, the other directives are cleared, and sparse sequences become regular ones.
+func (m *merger) clearDirectives(node *Node) (*Node, error) {
+	if node.sparse {
+		for i, child := range node.sequenceNodes {
+			if child.sequenceIndex != i {
+				return nil, sparseIndexError(node, child.sequenceIndex, i)
+			}
+		}
+		node.sparse = false
+	}
+	if node.style&yaml.TaggedStyle != 0 {
+		switch node.tag {
+		case AppendTag, PrependTag, UniqueTag, MergeTag:
+			strategy, sequence, err := sequenceDirective(node)
+			if err != nil {
+				return nil, err
+			}
+			node = sequence
+			if strategy.tag == UniqueTag {
+				node.sequenceNodes = uniqueScalars(node.sequenceNodes)
+			}
+		case ReplaceTag:
+			node.style &^= yaml.TaggedStyle
+			node.tag = ""
+		}
+	}
+	for _, key := range node.MappingKeys() {
+		if isDeletion(node.mappingNodes[key]) {
+			node.deleteMappingChild(key)
+			continue
+		}
+		child, err := m.clearDirectives(node.mappingNodes[key])
+		if err != nil {
+			return nil, err
+		}
+		node.mappingNodes[key] = child
+	}
+	if node.sequenceNodes != nil {
+		items := make([]*Node, 0, len(node.sequenceNodes))
+		for _, child := range node.sequenceNodes {
+			if isDeletion(child) {
+				continue
+			}
+			child, err := m.clearDirectives(child)
+			if err != nil {
+				return nil, err
+			}
+			// the items after the ones removed move up
+			child.sequenceIndex, child.hasSequenceIndex = len(items), true
+			items = append(items, child)
+		}
+		node.sequenceNodes = items
+	}
+	return node, nil
+}
+
+// inheritKeyComments sets the key comments of n that are missing from another, so the comments of a key are kept when
+// its value is overridden by a file without comments.
+func inheritKeyComments(n, another *Node) {
+	if n == another {
+		return
+	}
+	if n.keyHeadComment == "" {
+		n.keyHeadComment = another.keyHeadComment
+	}
+	if n.keyLineComment == "" {
+		n.keyLineComment = another.keyLineComment
+	}
+	if n.keyFootComment == "" {
+		n.keyFootComment = another.keyFootComment
+	}
+}
@@ -1,8 +1,11 @@
 package gofigure
 
 import (
+	"context"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/psanford/memfs"
 	"gopkg.in/yaml.v3"
 )
 
@@ -193,4 +196,278 @@ second: !append
 		Expect(s.Second).To(Equal([]string{"a", "b", "c"}))
 	})
 
+	It("should apply merge directives", func() {
+		loader := New()
+		Expect(loader.Load("app.yaml", []byte(`db:
+  host: localhost
+  port: 5432
+  options: {ssl: true, timeout: 5}
+cache:
+  host: localhost
+tags: [a, b]
+`))).To(BeNil())
+		Expect(loader.Load("app.yaml", []byte(`db:
+  port: !delete
+  options: !replace {ssl: false}
+  missing: !unset
+cache: !unset
+tags: !append [c]
+extra: !replace
+  nested: !delete
+  value: 1
+`))).To(BeNil())
+
+		var config map[string]any
+		Expect(loader.Get(context.Background(), "app", &config)).To(BeNil())
+		Expect(config).To(Equal(map[string]any{
+			"db":    map[string]any{"host": "localhost", "options": map[string]any{"ssl": false}},
+			"tags":  []any{"a", "b", "c"},
+			"extra": map[string]any{"value": 1},
+		}))
+
+		e, err := loader.Explain(context.Background(), "app.db")
+		Expect(err).To(BeNil())
+		Expect(e.String()).To(Equal(`app.db
+  set by app.yaml:2:3
+  set by app.yaml:2:3
+  set by app.yaml:2:9 !delete`))
+		e, err = loader.Explain(context.Background(), "app.db.options")
+		Expect(err).To(BeNil())
+		Expect(e.String()).To(Equal(`app.db.options
+  set by app.yaml:4:12
+  set by app.yaml:3:12 !replace`))
+		e, err = loader.Explain(context.Background(), "app.tags")
+		Expect(err).To(BeNil())
+		Expect(e.String()).To(Equal(`app.tags
+  set by app.yaml:7:7
+  set by app.yaml:6:7 !append`))
+		_, err = loader.Explain(context.Background(), "app.cache")
+		Expect(err).To(MatchError(ErrPathNotFound))
+	})
+
+	It("should clear merge directives with nothing to merge on", func() {
+		loader := New()
+		Expect(loader.Load("app.yaml", []byte(`db: !replace
+  host: localhost
+  port: !delete
+`))).To(BeNil())
+		node, err := loader.GetNode(context.Background(), "app.db")
+		Expect(err).To(BeNil())
+		Expect(node.Tag()).To(Equal(""))
+		Expect(node.MappingKeys()).To(Equal([]string{"host"}))
+	})
+
+	It("should remove values overridden by null only if null is unset", func() {
+		content := `db:
+  host: localhost
+  port: 5432
+`
+		override := `db:
+  port: ~
+  user: ~
+`
+		loader := New()
+		Expect(loader.Load("app.yaml", []byte(content))).To(BeNil())
+		Expect(loader.Load("app.yaml", []byte(override))).To(BeNil())
+		var config map[string]any
+		Expect(loader.Get(context.Background(), "app.db", &config)).To(BeNil())
+		Expect(config).To(Equal(map[string]any{"host": "localhost", "port": nil, "user": nil}))
+
+		loader = New().WithNullAsUnset()
+		Expect(loader.Load("app.yaml", []byte(content))).To(BeNil())
+		Expect(loader.Load("app.yaml", []byte(override))).To(BeNil())
+		config = nil
+		Expect(loader.Get(context.Background(), "app.db", &config)).To(BeNil())
+		Expect(config).To(Equal(map[string]any{"host": "localhost", "user": nil}))
+
+		_, err := loader.Reload(context.Background())
+		Expect(err).To(BeNil())
+		config = nil
+		Expect(loader.Get(context.Background(), "app.db", &config)).To(BeNil())
+		Expect(config).To(Equal(map[string]any{"host": "localhost", "user": nil}))
+
+		// set after loading, what was loaded is merged again
+		loader = New()
+		Expect(loader.Load("app.yaml", []byte(content))).To(BeNil())
+		Expect(loader.Load("app.yaml", []byte(override))).To(BeNil())
+		config = nil
+		Expect(loader.Get(context.Background(), "app.db", &config)).To(BeNil())
+		Expect(config).To(HaveKey("port"))
+		loader.WithNullAsUnset()
+		config = nil
+		Expect(loader.Get(context.Background(), "app.db", &config)).To(BeNil())
+		Expect(config).To(Equal(map[string]any{"host": "localhost", "user": nil}))
+		changed, err := loader.Reload(context.Background())
+		Expect(err).To(BeNil())
+		Expect(changed).To(BeEmpty())
+	})
+	It("should apply sequence merge directives", func() {
+		loader := New()
+		Expect(loader.Load("app.yaml", []byte(`hosts: [b, c]
+tags: [a, b]
+servers:
+- name: a
+  port: 1
+- name: b
+  port: 2
+- name: c
+  port: 3
+ports:
+- {id: x, port: 1}
+`))).To(BeNil())
+		Expect(loader.Load("app.yaml", []byte(`hosts: !prepend [a]
+tags: !unique [b, c, c]
+servers: !merge
+- name: b
+  port: 20
+  debug: true
+- !delete {name: c}
+- name: d
+  port: 4
+ports: !merge
+  key: id
+  items:
+  - {id: x, port: 10}
+  - {id: y, port: 20}
+fresh: !unique [a, a]
+`))).To(BeNil())
+
+		var config map[string]any
+		Expect(loader.Get(context.Background(), "app", &config)).To(BeNil())
+		Expect(config).To(Equal(map[string]any{
+			"hosts": []any{"a", "b", "c"},
+			"tags":  []any{"a", "b", "c"},
+			"servers": []any{
+				map[string]any{"name": "a", "port": 1},
+				map[string]any{"name": "b", "port": 20, "debug": true},
+				map[string]any{"name": "d", "port": 4},
+			},
+			"ports": []any{map[string]any{"id": "x", "port": 10}, map[string]any{"id": "y", "port": 20}},
+			"fresh": []any{"a"},
+		}))
+
+		node, err := loader.GetNode(context.Background(), "app.servers[2].port")
+		Expect(err).To(BeNil())
+		Expect(node.Keypath()).To(Equal("app.servers[2].port"))
+
+		e, err := loader.Explain(context.Background(), "app.servers")
+		Expect(err).To(BeNil())
+		Expect(e.String()).To(Equal(`app.servers
+  set by app.yaml:4:1
+  set by app.yaml:3:10 !merge
+  set by app.yaml:7:3 !delete`))
+		e, err = loader.Explain(context.Background(), "app.ports")
+		Expect(err).To(BeNil())
+		Expect(e.String()).To(Equal(`app.ports
+  set by app.yaml:11:1
+  set by app.yaml:10:8 !merge`))
+	})
+
+	It("should not apply invalid sequence merge directives", func() {
+		loader := New()
+		Expect(loader.Load("app.yaml", []byte(`tags: [a]`))).To(BeNil())
+		Expect(loader.Load("app.yaml", []byte(`tags: !prepend b`))).To(
+			MatchError(ContainSubstring("1:7@app.yaml: !prepend expects a sequence")))
+		Expect(loader.Load("app.yaml", []byte(`tags: !merge {key: id}`))).To(
+			MatchError(ContainSubstring("1:7@app.yaml: !merge expects a sequence of items")))
+		Expect(loader.Load("app.yaml", []byte(`tags: !merge {by: id, items: []}`))).To(
+			MatchError(ContainSubstring(`1:7@app.yaml: unknown !merge key "by", expected key or items`)))
+	})
+
+	It("should merge sequences with the strategy of their path", func() {
+		loader := New().
+			WithMergeStrategy("app.*", MergeUnique()).
+			WithMergeStrategy("**.servers", MergeByKey("host")).
+			WithMergeStrategy("app.tags", MergeAppend())
+		Expect(loader.Load("app.yaml", []byte(`servers: [{host: a, port: 1}]
+tags: [a]
+names: [a]
+hosts: [a]
+`))).To(BeNil())
+		Expect(loader.Load("app.yaml", []byte(`servers: [{host: a, port: 2}, {host: b, port: 3}]
+tags: [a]
+names: [a, b]
+hosts: !replace [b]
+`))).To(BeNil())
+
+		var config map[string]any
+		Expect(loader.Get(context.Background(), "app", &config)).To(BeNil())
+		Expect(config).To(Equal(map[string]any{
+			"servers": []any{map[string]any{"host": "a", "port": 2}, map[string]any{"host": "b", "port": 3}},
+			"tags":    []any{"a", "a"},
+			"names":   []any{"a", "b"},
+			"hosts":   []any{"b"},
+		}))
+	})
+
+	It("should number the items left once directives are cleared", func() {
+		loader := New()
+		Expect(loader.Load("b.yaml", []byte(`list: [a, !delete x, b]
+tags: !unique [a, a, b]`))).To(BeNil())
+		for path, value := range map[string]string{"b.list[1]": "b", "b.tags[1]": "b"} {
+			node, err := loader.GetNode(context.Background(), path)
+			Expect(err).To(BeNil())
+			Expect(node.Value()).To(Equal(value))
+			Expect(node.Keypath()).To(Equal(path))
+		}
+	})
+
+	It("should report the sources that fail to be merged again", func() {
+		fs := memfs.New()
+		Expect(fs.WriteFile("db.yaml", []byte(`host: localhost`), 0644)).To(BeNil())
+		var reloadErr error
+		var notified int
+		loader := New().OnReload(func(_ []string, err error) {
+			reloadErr = err
+		})
+		Expect(loader.Load("app.yaml", []byte(`port: 80`))).To(BeNil())
+		Expect(loader.Load("app.yaml", []byte(`port: ~`))).To(BeNil())
+		Expect(loader.LoadFS(fs)).To(BeNil())
+		loader.OnChange("app", func(old, new *Node) {
+			notified++
+		})
+
+		Expect(fs.WriteFile("db.yaml", []byte(`host: [`), 0644)).To(BeNil())
+		loader.WithNullAsUnset()
+		Expect(reloadErr).To(MatchError(ContainSubstring("unable to merge again")))
+		_, err := loader.GetNode(context.Background(), "app")
+		Expect(err).To(MatchError(ContainSubstring("unable to merge again")))
+		Expect(notified).To(BeZero())
+
+		Expect(fs.WriteFile("db.yaml", []byte(`host: localhost`), 0644)).To(BeNil())
+		_, err = loader.Reload(context.Background())
+		Expect(err).To(BeNil())
+		node, err := loader.GetNode(context.Background(), "app")
+		Expect(err).To(BeNil())
+		Expect(node.MappingKeys()).To(BeEmpty())
+		Expect(notified).To(Equal(1))
+	})
+
+	It("should notify the subscribers of the values merged again", func() {
+		loader := New()
+		Expect(loader.Load("app.yaml", []byte(`port: 80`))).To(BeNil())
+		Expect(loader.Load("app.yaml", []byte(`port: ~`))).To(BeNil())
+		var notified int
+		loader.OnChange("app.port", func(old, new *Node) {
+			notified++
+			Expect(old.Tag()).To(Equal("!!null"))
+			Expect(new).To(BeNil())
+		})
+		loader.WithNullAsUnset()
+		Expect(notified).To(Equal(1))
+	})
+
+	It("should merge again with a strategy set after loading", func() {
+		loader := New()
+		Expect(loader.Load("app.yaml", []byte(`tags: [a]`))).To(BeNil())
+		Expect(loader.Load("app.yaml", []byte(`tags: [b]`))).To(BeNil())
+		var tags []string
+		Expect(loader.Get(context.Background(), "app.tags", &tags)).To(BeNil())
+		Expect(tags).To(Equal([]string{"b"}))
+
+		loader.WithMergeStrategy("app.tags", MergeAppend())
+		tags = nil
+		Expect(loader.Get(context.Background(), "app.tags", &tags)).To(BeNil())
+		Expect(tags).To(Equal([]string{"a", "b"}))
+	})
 })
@@ -2,6 +2,7 @@ package gofigure
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	"gopkg.in/yaml.v3"
@@ -19,7 +20,15 @@ type Node struct {
 	line        int
 	column      int
 
+	// comments of the mapping key the node is the value of
+	keyHeadComment string
+	keyLineComment string
+	keyFootComment string
+
 	filepath string
+	source   string
+	// every place the node was set at, if it has been merged, see Origins
+	origins []Origin
 
 	parent           *Node
 	sequenceIndex    int
@@ -29,8 +38,21 @@ type Node struct {
 
 	resolved     bool
 	resolvedNode *Node
+	// resolving is set while a feature resolves the node, to detect circular references, resolveErr is set if it
+	// failed to resolve
+	resolving  bool
+	resolveErr error
+
+	// sensitive nodes hold a value such as a password, see Sensitive
+	sensitive bool
+
+	// sparse sequences hold elements at their sequenceIndex, which are merged into the elements at the same index of
+	// the sequence they are merged into, instead of replacing it.
+	sparse bool
 
-	mappingNodes  map[string]*Node
+	mappingNodes map[string]*Node
+	// keys of mappingNodes in the order they were set in
+	mappingKeys   []string
 	sequenceNodes []*Node
 }
 
@@ -41,11 +63,13 @@ func createNodeWithOptions(options ...NodeOption) *Node {
 	}
 	n := &Node{
 		filepath:         o.filepath,
+		source:           o.source,
 		parent:           o.parent,
 		sequenceIndex:    o.sequenceIndex,
 		hasSequenceIndex: o.hasSequenceIndex,
 		mappingKey:       o.mappingKey,
 		hasMappingKey:    o.hasMappingKey,
+		sensitive:        o.sensitive,
 	}
 	return n
 }
@@ -61,6 +85,7 @@ func NewMappingNode(m map[string]*Node, options ...NodeOption) *Node {
 	n := createNodeWithOptions(options...)
 	n.kind = yaml.MappingNode
 	n.mappingNodes = m
+	markSensitive(n, false)
 	return n
 }
 
@@ -68,12 +93,25 @@ func NewSequenceNode(values []*Node, options ...NodeOption) *Node {
 	n := createNodeWithOptions(options...)
 	n.kind = yaml.SequenceNode
 	n.sequenceNodes = values
+	markSensitive(n, false)
 	return n
 }
 
 func NewNode(node *yaml.Node, options ...NodeOption) *Node {
+	o := &nodeOptions{}
+	for i := range options {
+		options[i].apply(o)
+	}
 	n := createNodeWithOptions(options...)
-	n.unmarshalNode(node)
+	n.unmarshalNode(node, o.anchors)
+	markSensitive(n, n.parent != nil && n.parent.sensitive)
+	return n
+}
+
+// newYAMLNode creates the node of a child of a YAML node, which is marked sensitive along with its parent.
+func newYAMLNode(node *yaml.Node, anchors map[string]*Node) *Node {
+	n := &Node{}
+	n.unmarshalNode(node, anchors)
 	return n
 }
 
@@ -89,17 +127,35 @@ func (n *Node) Filepath() string {
 	return ""
 }
 
+// Source returns where the node comes from: the file path for nodes loaded from files, or a description such as
+// "env:APP__PORT" for nodes loaded from elsewhere.
+func (n *Node) Source() string {
+	if n.source != "" {
+		return n.source
+	}
+
+	if n.filepath != "" {
+		return n.filepath
+	}
+
+	if n.parent != nil {
+		return n.parent.Source()
+	}
+
+	return ""
+}
+
 func (n *Node) Keypath() string {
 	var key string
 	cur := n
 	for cur != nil {
+		dot := "."
+		if strings.HasPrefix(key, "[") {
+			dot = ""
+		}
 		if cur.hasSequenceIndex {
-			key = fmt.Sprintf("[%d]%s", cur.sequenceIndex, key)
+			key = fmt.Sprintf("[%d]%s%s", cur.sequenceIndex, dot, key)
 		} else if cur.hasMappingKey {
-			dot := "."
-			if strings.HasPrefix(key, "[") {
-				dot = ""
-			}
 			key = fmt.Sprintf("%s%s%s", cur.mappingKey, dot, key)
 		} else {
 			break
@@ -117,6 +173,57 @@ func (n *Node) GetMappingChild(key string) (*Node, error) {
 	return n.mappingNodes[key], nil
 }
 
+// MappingKeys returns the keys of a mapping node, in the order they were first set in: the order of the file they
+// were loaded from, followed by the keys added by merging. Keys set directly in the map given to NewMappingNode come
+// last, sorted.
+func (n *Node) MappingKeys() []string {
+	keys := make([]string, 0, len(n.mappingNodes))
+	seen := make(map[string]bool, len(n.mappingNodes))
+	for _, key := range n.mappingKeys {
+		if _, ok := n.mappingNodes[key]; ok && !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) < len(n.mappingNodes) {
+		var rest []string
+		for key := range n.mappingNodes {
+			if !seen[key] {
+				rest = append(rest, key)
+			}
+		}
+		sort.Strings(rest)
+		keys = append(keys, rest...)
+	}
+	return keys
+}
+
+// setMappingChild sets the child at key, appending key to the keys if it is new.
+func (n *Node) setMappingChild(key string, child *Node) {
+	if _, ok := n.mappingNodes[key]; !ok {
+		if len(n.mappingKeys) < len(n.mappingNodes) {
+			// keys set by NewMappingNode come before the new one
+			n.mappingKeys = n.MappingKeys()
+		}
+		n.mappingKeys = append(n.mappingKeys, key)
+	}
+	n.mappingNodes[key] = child
+}
+
+// deleteMappingChild removes the child at key, along with key.
+func (n *Node) deleteMappingChild(key string) {
+	if len(n.mappingKeys) < len(n.mappingNodes) {
+		n.mappingKeys = n.MappingKeys()
+	}
+	delete(n.mappingNodes, key)
+	for i, k := range n.mappingKeys {
+		if k == key {
+			n.mappingKeys = append(n.mappingKeys[:i:i], n.mappingKeys[i+1:]...)
+			break
+		}
+	}
+}
+
 func (n *Node) GetSequenceChild(index int) (*Node, error) {
 	if n.kind != yaml.SequenceNode {
 		return nil, fmt.Errorf("%q is not a sequence node", n.Keypath())
@@ -127,6 +234,15 @@ func (n *Node) GetSequenceChild(index int) (*Node, error) {
 	return n.sequenceNodes[index], nil
 }
 
+func (n *Node) sparseSequenceChild(index int) *Node {
+	for _, child := range n.sequenceNodes {
+		if child.sequenceIndex == index {
+			return child
+		}
+	}
+	return nil
+}
+
 func (n *Node) GetDeep(path string) (*Node, error) {
 	if path == "" {
 		return n, nil
@@ -158,30 +274,40 @@ func (n *Node) GetDeep(path string) (*Node, error) {
 	return current, nil
 }
 
+// MarshalYAML marshals the node with its sensitive values redacted, see Sensitive. Marshal ToYAMLNode to reveal them.
 func (n *Node) MarshalYAML() (interface{}, error) {
-	return n.ToYAMLNode(), nil
+	return n.ToRedactedYAMLNode(), nil
 }
 
 func (n *Node) UnmarshalYAML(value *yaml.Node) error {
-	n.unmarshalNode(value)
+	n.unmarshalNode(value, nil)
+	markSensitive(n, false)
 	return nil
 }
 
-func (n *Node) unmarshalNode(node *yaml.Node) {
+// unmarshalNode sets n to node, the aliases to the placeholders of anchors are set to copies of them, see NodeAnchors.
+func (n *Node) unmarshalNode(node *yaml.Node, anchors map[string]*Node) {
 	n.kind = node.Kind
 	n.style = node.Style
 	n.value = node.Value
 	n.tag = node.Tag
+	if n.tag == "!sensitive" {
+		// the value is typed as a plain scalar, it is not resolved by any feature
+		n.sensitive = true
+		n.tag = ""
+		n.style &^= yaml.TaggedStyle
+	}
 	n.anchor = node.Anchor
 	n.headComment = node.HeadComment
 	n.lineComment = node.LineComment
 	n.footComment = node.FootComment
 	n.line = node.Line
 	n.column = node.Column
-	setNodeValueFromYAML(n, node)
+	n.sensitive = n.sensitive || hasSensitiveAnnotation(n)
+	setNodeValueFromYAML(n, node, anchors)
 }
 
-func setNodeValueFromYAML(n *Node, node *yaml.Node) {
+func setNodeValueFromYAML(n *Node, node *yaml.Node, anchors map[string]*Node) {
 	switch node.Kind {
 	case yaml.DocumentNode, yaml.ScalarNode:
 	case yaml.MappingNode:
@@ -189,31 +315,94 @@ func setNodeValueFromYAML(n *Node, node *yaml.Node) {
 		for i := 0; i < len(node.Content); i += 2 {
 			keyNode := node.Content[i]
 			valueNode := node.Content[i+1]
-			childNode := NewNode(valueNode)
+			if keyNode.Tag == "!!merge" {
+				if sources, ok := mergeKeySources(valueNode, anchors); ok {
+					n.setMergedChildren(sources)
+					continue
+				}
+			}
+			// the keys set in the mapping itself replace the merged ones
+			childNode := newYAMLNode(valueNode, anchors)
 			childNode.parent = n
 			childNode.mappingKey = keyNode.Value
 			childNode.hasMappingKey = true
-			n.mappingNodes[keyNode.Value] = childNode
+			childNode.keyHeadComment = keyNode.HeadComment
+			childNode.keyLineComment = keyNode.LineComment
+			childNode.keyFootComment = keyNode.FootComment
+			childNode.sensitive = childNode.sensitive || hasSensitiveAnnotation(childNode)
+			n.setMappingChild(keyNode.Value, childNode)
 		}
 	case yaml.SequenceNode:
 		n.sequenceNodes = make([]*Node, len(node.Content))
 		for i := range node.Content {
-			childNode := NewNode(node.Content[i])
+			childNode := newYAMLNode(node.Content[i], anchors)
 			childNode.parent = n
 			childNode.sequenceIndex = i
 			childNode.hasSequenceIndex = true
 			n.sequenceNodes[i] = childNode
 		}
 	case yaml.AliasNode:
+		if anchor, ok := anchors[node.Alias.Anchor]; ok && node.Alias.Tag == anchorTag {
+			n.setAnchorValue(anchor)
+			return
+		}
 		n.kind = node.Alias.Kind
 		n.value = node.Alias.Value
-		setNodeValueFromYAML(n, node.Alias)
+		setNodeValueFromYAML(n, node.Alias, anchors)
+	}
+}
+
+// mergeKeySources returns the mappings the value of a << merge key merges, which is either a mapping or a sequence
+// of mappings, usually aliases.
+func mergeKeySources(node *yaml.Node, anchors map[string]*Node) ([]*Node, bool) {
+	value := newYAMLNode(node, anchors)
+	sources := []*Node{value}
+	if value.kind == yaml.SequenceNode {
+		sources = value.sequenceNodes
+	}
+	for _, source := range sources {
+		if source.kind != yaml.MappingNode {
+			return nil, false
+		}
+	}
+	return sources, true
+}
+
+// setMergedChildren sets the keys of sources that are not set yet, so the keys of the first sources take precedence.
+// The children keep the file of their source, for the anchors of other files.
+func (n *Node) setMergedChildren(sources []*Node) {
+	for _, source := range sources {
+		for _, key := range source.MappingKeys() {
+			if _, ok := n.mappingNodes[key]; ok {
+				continue
+			}
+			childNode := source.mappingNodes[key]
+			childNode.parent = n
+			if childNode.filepath == "" && childNode.source == "" {
+				childNode.filepath, childNode.source = source.filepath, source.source
+			}
+			n.setMappingChild(key, childNode)
+		}
 	}
 }
 
 func (n *Node) ToYAMLNode() *yaml.Node {
+	return n.toYAMLNode(false)
+}
+
+// ToRedactedYAMLNode is like ToYAMLNode, with every sensitive scalar replaced with Redacted.
+func (n *Node) ToRedactedYAMLNode() *yaml.Node {
+	return n.toYAMLNode(true)
+}
+
+func (n *Node) toYAMLNode(redact bool) *yaml.Node {
+	if redact && n.Sensitive() {
+		node := n.toYAMLNode(false)
+		redactYAML(node)
+		return node
+	}
 	if n.resolved && n.resolvedNode != nil {
-		return n.resolvedNode.ToYAMLNode()
+		return n.resolvedNode.toYAMLNode(redact)
 	}
 
 	var node yaml.Node
@@ -232,15 +421,20 @@ func (n *Node) ToYAMLNode() *yaml.Node {
 	case yaml.DocumentNode, yaml.AliasNode, yaml.ScalarNode:
 	case yaml.MappingNode:
 		node.Content = nil
-		for key, childNode := range n.mappingNodes {
+		for _, key := range n.MappingKeys() {
+			childNode := n.mappingNodes[key]
 			if childNode == nil {
 				continue
 			}
 			keyNode := &yaml.Node{
-				Kind:  yaml.ScalarNode,
-				Value: key,
+				Kind:        yaml.ScalarNode,
+				Tag:         "!!str",
+				Value:       key,
+				HeadComment: childNode.keyHeadComment,
+				LineComment: childNode.keyLineComment,
+				FootComment: childNode.keyFootComment,
 			}
-			node.Content = append(node.Content, keyNode, childNode.ToYAMLNode())
+			node.Content = append(node.Content, keyNode, childNode.toYAMLNode(redact))
 		}
 	case yaml.SequenceNode:
 		node.Content = nil
@@ -248,7 +442,7 @@ func (n *Node) ToYAMLNode() *yaml.Node {
 			if childNode == nil {
 				continue
 			}
-			node.Content = append(node.Content, childNode.ToYAMLNode())
+			node.Content = append(node.Content, childNode.toYAMLNode(redact))
 		}
 	}
 
@@ -270,6 +464,25 @@ func (n *Node) Value() string {
 	return n.value
 }
 
+// Resolved returns the value the node is resolved to by a feature, or the node itself if it has no tag or is not
+// resolved yet. The children of mappings and sequences are returned as they are, and should be resolved in turn.
+func (n *Node) Resolved() *Node {
+	return resolvedValue(n)
+}
+
+// Sensitive reports whether the node holds a sensitive value, such as a password, which is the case if the node, the
+// value it is resolved to, or any node above either of them is marked as sensitive, by the !sensitive tag, the
+// "# gofigure:sensitive" annotation, Loader.WithSensitivePaths or a feature such as !secret.
+func (n *Node) Sensitive() bool {
+	// the nodes under a sensitive node are marked as they are loaded, see markSensitive
+	for ; n != nil; n = n.resolvedNode {
+		if n.sensitive {
+			return true
+		}
+	}
+	return false
+}
+
 func (n *Node) RawValue() string {
 	return n.value
 }
@@ -294,6 +507,10 @@ func (n *Node) FootComment() string {
 	return n.footComment
 }
 
+func (n *Node) KeyHeadComment() string {
+	return n.keyHeadComment
+}
+
 func (n *Node) Line() int {
 	return n.line
 }
@@ -313,3 +530,38 @@ func (n *Node) BoolValue() (bool, error) {
 	}
 	return b, nil
 }
+
+// clone returns a deep copy of the node. Children merged from another file keep their parent in that file, so they
+// still report where they come from, the others are attached to the copy.
+func (n *Node) clone() *Node {
+	if n == nil {
+		return nil
+	}
+
+	c := *n
+	c.mappingKeys = append([]string(nil), n.mappingKeys...)
+	if n.resolvedNode != nil {
+		c.resolvedNode = n.resolvedNode.clone()
+	}
+	if n.mappingNodes != nil {
+		c.mappingNodes = make(map[string]*Node, len(n.mappingNodes))
+		for key, child := range n.mappingNodes {
+			c.mappingNodes[key] = c.adopt(n, child.clone())
+		}
+	}
+	if n.sequenceNodes != nil {
+		c.sequenceNodes = make([]*Node, len(n.sequenceNodes))
+		for i, child := range n.sequenceNodes {
+			c.sequenceNodes[i] = c.adopt(n, child.clone())
+		}
+	}
+	return &c
+}
+
+// adopt attaches the copy of a child of original to n, if original is its parent.
+func (n *Node) adopt(original, child *Node) *Node {
+	if child != nil && child.parent == original {
+		child.parent = n
+	}
+	return child
+}
@@ -2,11 +2,15 @@ package gofigure
 
 type nodeOptions struct {
 	filepath         string
+	source           string
 	mappingKey       string
 	hasMappingKey    bool
 	sequenceIndex    int
 	hasSequenceIndex bool
 	parent           *Node
+	sensitive        bool
+	// anchors of other files, only used by decoders
+	anchors map[string]*Node
 }
 
 type NodeOption interface {
@@ -25,6 +29,12 @@ func NodeFilepath(path string) NodeOption {
 	})
 }
 
+func NodeSource(source string) NodeOption {
+	return nodeOptionFunc(func(o *nodeOptions) {
+		o.source = source
+	})
+}
+
 func NodeSequenceIndex(index int) NodeOption {
 	return nodeOptionFunc(func(o *nodeOptions) {
 		o.sequenceIndex = index
@@ -44,3 +54,10 @@ func NodeParent(parent *Node) NodeOption {
 		o.parent = parent
 	})
 }
+
+// NodeSensitive marks the node as holding a sensitive value, such as a password, see Node.Sensitive.
+func NodeSensitive() NodeOption {
+	return nodeOptionFunc(func(o *nodeOptions) {
+		o.sensitive = true
+	})
+}
@@ -85,6 +85,21 @@ var _ = Describe("Node", func() {
 			sequenceIndex:    2,
 		}
 		Expect(node.Keypath()).To(Equal("parent.some[2]"))
+
+		node = &Node{
+			hasMappingKey: true,
+			mappingKey:    "name",
+			parent: &Node{
+				hasSequenceIndex: true,
+				sequenceIndex:    0,
+				parent: &Node{
+					hasMappingKey: true,
+					mappingKey:    "servers",
+					parent:        &Node{},
+				},
+			},
+		}
+		Expect(node.Keypath()).To(Equal("servers[0].name"))
 	})
 
 	It("should get mapping child", func() {
@@ -215,6 +230,53 @@ var _ = Describe("Node", func() {
 			),
 		})))
 	})
+
+	It("should get the resolved node", func() {
+		node := NewScalarNode("raw")
+		Expect(node.Resolved()).To(BeIdenticalTo(node))
+		node.resolved = true
+		node.resolvedNode = NewScalarNode("resolved")
+		Expect(node.Resolved().Value()).To(Equal("resolved"))
+	})
+
+	It("should keep the keys of a mapping node before the ones set after", func() {
+		node := NewMappingNode(map[string]*Node{"b": NewScalarNode("1"), "a": NewScalarNode("2")})
+		node.setMappingChild("c", NewScalarNode("3"))
+		node.setMappingChild("a", NewScalarNode("4"))
+		Expect(node.MappingKeys()).To(Equal([]string{"a", "b", "c"}))
+	})
+
+	It("should marshal in file order with comments", func() {
+		content := `# head of document
+z: 1 # line of z
+# head of a
+a:
+    # head of c
+    c: true
+    b: [1, 2]
+# foot of a
+
+m: value
+`
+		var node Node
+		Expect(yaml.Unmarshal([]byte(content), &node)).To(BeNil())
+		for i := 0; i < 10; i++ {
+			b, err := yaml.Marshal(&node)
+			Expect(err).To(BeNil())
+			Expect(string(b)).To(Equal(content))
+		}
+	})
+
+	It("should be sensitive if its resolved value is", func() {
+		secret := NewScalarNode("password", NodeSensitive())
+		Expect(secret.Sensitive()).To(BeTrue())
+
+		node := NewScalarNode("db/password")
+		Expect(node.Sensitive()).To(BeFalse())
+		node.resolved, node.resolvedNode = true, secret
+		Expect(node.Sensitive()).To(BeTrue())
+		Expect(node.clone().Sensitive()).To(BeTrue())
+	})
 })
 
 var _ = DescribeTable("Node BoolValue", func(value string, b bool) {
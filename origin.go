@@ -0,0 +1,144 @@
+package gofigure
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Origin is a place a value was set at, e.g. a line of a file or an environment variable.
+type Origin struct {
+	// Source is the file path, or a description such as "env:APP__PORT" for values not loaded from files.
+	Source string
+	// Line and Column are zero for values not loaded from files.
+	Line   int
+	Column int
+	// Tag and Value are the raw tag and value set, before resolution. Value is empty for mappings and sequences.
+	Tag   string
+	Value string
+}
+
+func (o Origin) String() string {
+	position := o.Position()
+	tagged := o.Tag != "" && !strings.HasPrefix(o.Tag, "!!")
+	if o.Value == "" {
+		// e.g. the merge directives
+		if tagged {
+			return fmt.Sprintf("%s %s", position, o.Tag)
+		}
+		return position
+	}
+	if tagged {
+		return fmt.Sprintf("%s %s %q", position, o.Tag, o.Value)
+	}
+	return fmt.Sprintf("%s %q", position, o.Value)
+}
+
+// Position returns the source of the origin, followed by the line and column for files.
+func (o Origin) Position() string {
+	if o.Line > 0 {
+		return fmt.Sprintf("%s:%d:%d", o.Source, o.Line, o.Column)
+	}
+	return o.Source
+}
+
+// Origins returns every place the node was set at, in the order they were loaded, the last one is in effect for
+// scalars. Mappings and sequences merged from several places list all of them.
+func (n *Node) Origins() []Origin {
+	if len(n.origins) > 0 {
+		return append([]Origin(nil), n.origins...)
+	}
+	return []Origin{n.origin()}
+}
+
+func (n *Node) origin() Origin {
+	o := Origin{
+		Source: n.Source(),
+		Line:   n.line,
+		Column: n.column,
+		Tag:    n.tag,
+	}
+	if n.kind == yaml.ScalarNode {
+		o.Value = n.value
+	}
+	return o
+}
+
+// chainOrigins returns the origins of n followed by the ones of another.
+func chainOrigins(n, another *Node) []Origin {
+	return append(n.Origins(), another.Origins()...)
+}
+
+// Explanation describes how the value at a path came to be.
+type Explanation struct {
+	Path string
+	// Value is the resolved value.
+	Value *Node
+	// Origins are every place the value was set at, in order, see Node.Origins.
+	Origins []Origin
+	// Feature is the tag of the feature that resolved the value, if any, from the raw value RawValue.
+	Feature  string
+	RawValue string
+	// Sensitive is whether the value is sensitive, see Node.Sensitive, in which case String redacts every value.
+	Sensitive bool
+}
+
+func (e *Explanation) String() string {
+	var sb strings.Builder
+	sb.WriteString(e.Path)
+	if e.Value != nil && e.Value.Kind() == yaml.ScalarNode {
+		value := e.Value.Value()
+		if e.Sensitive {
+			value = Redacted
+		}
+		fmt.Fprintf(&sb, " = %q", value)
+	}
+	for _, o := range e.Origins {
+		if e.Sensitive && o.Value != "" {
+			o.Value = Redacted
+		}
+		fmt.Fprintf(&sb, "\n  set by %s", o)
+	}
+	if e.Feature != "" {
+		rawValue := e.RawValue
+		if e.Sensitive {
+			rawValue = Redacted
+		}
+		fmt.Fprintf(&sb, "\n  resolved by %s from %q", e.Feature, rawValue)
+	}
+	return sb.String()
+}
+
+// Explain returns the override chain of the value at path, and how it was resolved. It returns ErrPathNotFound if
+// there is no value at path.
+func (l *Loader) Explain(ctx context.Context, path string) (*Explanation, error) {
+	snapshot, err := l.current(ctx)
+	if err != nil {
+		return nil, err
+	}
+	value, err := snapshot.lookup(path)
+	if err != nil {
+		return nil, err
+	}
+	if value == nil {
+		return nil, fmt.Errorf("%s: %w", path, ErrPathNotFound)
+	}
+	node, err := snapshot.lookupRaw(path)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &Explanation{
+		Path:      path,
+		Value:     value,
+		Origins:   node.Origins(),
+		Sensitive: node.Sensitive(),
+	}
+	if node.resolvedNode != nil {
+		e.Feature = node.tag
+		e.RawValue = node.value
+	}
+	return e, nil
+}
@@ -0,0 +1,86 @@
+package gofigure
+
+import (
+	"context"
+	"flag"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Explain", func() {
+	var loader *Loader
+
+	BeforeEach(func() {
+		loader = New().WithFeatures(refFeature)
+		Expect(loader.Load("app.yaml", []byte(`env: dev
+port: 8080
+listen: !ref app.port
+tags: [a]`))).To(BeNil())
+		Expect(loader.Load("app.json", []byte(`{
+  "port": 80,
+  "tags": ["b"]
+}`))).To(BeNil())
+		Expect(loader.LoadEnv(EnvPrefix("APP"), EnvEnviron([]string{"APP__APP__PORT=8000"}))).To(BeNil())
+
+		fs := flag.NewFlagSet("app", flag.ContinueOnError)
+		fs.String("app.port", "", "")
+		Expect(fs.Parse([]string{"-app.port=9000"})).To(BeNil())
+		loader.BindFlags(StdFlagSet(fs))
+	})
+
+	It("should explain overridden values", func() {
+		e, err := loader.Explain(context.Background(), "app.port")
+		Expect(err).To(BeNil())
+		Expect(e.Path).To(Equal("app.port"))
+		Expect(e.Value.Value()).To(Equal("9000"))
+		Expect(e.Origins).To(Equal([]Origin{
+			{Source: "app.yaml", Line: 2, Column: 7, Tag: "!!int", Value: "8080"},
+			{Source: "app.json", Line: 2, Column: 11, Tag: "!!int", Value: "80"},
+			{Source: "env:APP__APP__PORT", Tag: "!!int", Value: "8000"},
+			{Source: "flag:app.port", Tag: "!!int", Value: "9000"},
+		}))
+		Expect(e.Feature).To(BeEmpty())
+		Expect(e.String()).To(Equal(`app.port = "9000"
+  set by app.yaml:2:7 "8080"
+  set by app.json:2:11 "80"
+  set by env:APP__APP__PORT "8000"
+  set by flag:app.port "9000"`))
+
+		e, err = loader.Explain(context.Background(), "app.env")
+		Expect(err).To(BeNil())
+		Expect(e.Origins).To(Equal([]Origin{{Source: "app.yaml", Line: 1, Column: 6, Tag: "!!str", Value: "dev"}}))
+	})
+
+	It("should explain resolved values", func() {
+		e, err := loader.Explain(context.Background(), "app.listen")
+		Expect(err).To(BeNil())
+		Expect(e.Value.Value()).To(Equal("9000"))
+		Expect(e.Origins).To(Equal([]Origin{{Source: "app.yaml", Line: 3, Column: 9, Tag: "!ref", Value: "app.port"}}))
+		Expect(e.Feature).To(Equal("!ref"))
+		Expect(e.RawValue).To(Equal("app.port"))
+		Expect(e.String()).To(Equal(`app.listen = "9000"
+  set by app.yaml:3:9 !ref "app.port"
+  resolved by !ref from "app.port"`))
+	})
+
+	It("should explain merged values", func() {
+		e, err := loader.Explain(context.Background(), "app")
+		Expect(err).To(BeNil())
+		Expect(e.Origins).To(HaveLen(4))
+		Expect(e.Origins[0].Source).To(Equal("app.yaml"))
+		Expect(e.Origins[1].Source).To(Equal("app.json"))
+		Expect(e.Origins[2].Source).To(Equal("env"))
+		Expect(e.Origins[3].Source).To(Equal("flags"))
+
+		e, err = loader.Explain(context.Background(), "app.tags")
+		Expect(err).To(BeNil())
+		Expect(e.Origins).To(HaveLen(2))
+		Expect(e.Origins[1].Source).To(Equal("app.json"))
+	})
+
+	It("should fail when the path does not exist", func() {
+		_, err := loader.Explain(context.Background(), "app.missing")
+		Expect(err).To(MatchError(ErrPathNotFound))
+	})
+})
@@ -0,0 +1,85 @@
+package gofigure
+
+import (
+	"fmt"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// setOverlayValue sets value at paths under the mapping node root, creating mapping and sparse sequence nodes along
+// the way. The scalar node holding the value is marked with source, the nodes along the way with the source of root.
+func setOverlayValue(root *Node, paths []*DotPath, value, source string) error {
+	current := root
+	for i, p := range paths {
+		rest := paths[i+1:]
+		var child *Node
+		if p.Key == "" {
+			if current.kind != yaml.SequenceNode {
+				return fmt.Errorf("%q is not a sequence", current.Keypath())
+			}
+			child = current.sparseSequenceChild(p.Index)
+			if child == nil {
+				child = newOverlayNode(root, rest, source, NodeParent(current), NodeSequenceIndex(p.Index))
+				current.sequenceNodes = append(current.sequenceNodes, child)
+				sort.SliceStable(current.sequenceNodes, func(i, j int) bool {
+					return current.sequenceNodes[i].sequenceIndex < current.sequenceNodes[j].sequenceIndex
+				})
+			}
+		} else {
+			if current.kind != yaml.MappingNode {
+				return fmt.Errorf("%q is not a mapping", current.Keypath())
+			}
+			child = current.mappingNodes[p.Key]
+			if child == nil {
+				child = newOverlayNode(root, rest, source, NodeParent(current), NodeMappingKey(p.Key))
+				current.setMappingChild(p.Key, child)
+			}
+		}
+
+		if len(rest) == 0 {
+			if child.kind != yaml.ScalarNode {
+				return fmt.Errorf("%q is already set by a nested value", child.Keypath())
+			}
+			setInferredScalarValue(child, value)
+			return nil
+		}
+		if child.kind == yaml.ScalarNode {
+			return fmt.Errorf("%q is already set", child.Keypath())
+		}
+		current = child
+	}
+	return nil
+}
+
+// newOverlayNode creates the node for a path segment, its kind is decided by the segments after it.
+func newOverlayNode(root *Node, rest []*DotPath, source string, options ...NodeOption) *Node {
+	if len(rest) == 0 {
+		return NewScalarNode("", append(options, NodeSource(source))...)
+	}
+
+	options = append(options, NodeSource(root.source))
+	if rest[0].Key == "" {
+		n := NewSequenceNode(nil, options...)
+		n.sparse = true
+		return n
+	}
+	return NewMappingNode(map[string]*Node{}, options...)
+}
+
+// setInferredScalarValue sets value to a scalar node, the type is inferred the same way as plain YAML scalars.
+func setInferredScalarValue(n *Node, value string) {
+	n.value = value
+
+	var yamlNode yaml.Node
+	if err := yaml.Unmarshal([]byte(value), &yamlNode); err == nil && len(yamlNode.Content) == 1 {
+		scalar := yamlNode.Content[0]
+		if scalar.Kind == yaml.ScalarNode && scalar.Style == 0 && scalar.Value == value {
+			n.tag = scalar.Tag
+			return
+		}
+	}
+
+	n.tag = "!!str"
+	n.style = yaml.DoubleQuotedStyle
+}
@@ -0,0 +1,69 @@
+package gofigure
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf16"
+)
+
+// encodeProperties writes an exported node tree as a Java properties file, a line per scalar keyed by its dot path.
+func encodeProperties(w io.Writer, node *Node, provenance bool) error {
+	var buf bytes.Buffer
+	err := eachScalar(node, nil, func(_ []string, node *Node) error {
+		v, err := scalarValue(node)
+		if err != nil {
+			return err
+		}
+		value := ""
+		if v != nil {
+			value = node.value
+		}
+
+		if provenance {
+			buf.WriteString("# " + provenanceComment(node) + "\n")
+		}
+		buf.WriteString(propertiesEscape(node.Keypath(), true) + "=" + propertiesEscape(value, false) + "\n")
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// propertiesEscape escapes a key or a value of a properties file, which is read as ISO 8859-1, so every other
+// character is written as a unicode escape.
+func propertiesEscape(s string, isKey bool) string {
+	var sb strings.Builder
+	for i, r := range s {
+		switch {
+		case r == '\\':
+			sb.WriteString(`\\`)
+		case r == '\n':
+			sb.WriteString(`\n`)
+		case r == '\r':
+			sb.WriteString(`\r`)
+		case r == '\t':
+			sb.WriteString(`\t`)
+		case r == '\f':
+			sb.WriteString(`\f`)
+		case r == ' ' && (isKey || i == 0):
+			sb.WriteString(`\ `)
+		case isKey && strings.ContainsRune("=:#!", r):
+			sb.WriteString(`\` + string(r))
+		case r < 0x20 || r > 0x7e:
+			if r > 0xffff {
+				r1, r2 := utf16.EncodeRune(r)
+				fmt.Fprintf(&sb, `\u%04x\u%04x`, r1, r2)
+			} else {
+				fmt.Fprintf(&sb, `\u%04x`, r)
+			}
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
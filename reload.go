@@ -0,0 +1,363 @@
+package gofigure
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ReloadFunc is called after a reload with the dot paths whose resolved values have changed, or with the error that
+// failed the reload, in which case the last good config is kept.
+type ReloadFunc func(changed []string, err error)
+
+// OnReload registers fn to be called after every reload that changed the config or failed.
+func (l *Loader) OnReload(fn ReloadFunc) *Loader {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.reloadFuncs = append(l.reloadFuncs, fn)
+	return l
+}
+
+// Reload loads everything loaded so far again, e.g. files loaded by LoadFS are read again. The new config is fully
+// resolved before it replaces the current one, so a config that fails to parse or resolve is never swapped in. Features
+// that are not cacheable resolve their values again, as anything they read may have changed, the results of the
+// cacheable ones are reused if their nodes and the values they depend on are unchanged. It returns the dot paths whose resolved values have
+// changed, sorted, and notifies the subscribers of the changed paths as well as the functions registered by OnReload.
+func (l *Loader) Reload(ctx context.Context) ([]string, error) {
+	changed, notifications, err := l.reload(ctx)
+	notify(notifications)
+	if err != nil || len(changed) > 0 {
+		l.mu.Lock()
+		reloadFuncs := l.reloadFuncs
+		l.mu.Unlock()
+		for _, fn := range reloadFuncs {
+			fn(changed, err)
+		}
+	}
+	return changed, err
+}
+
+func (l *Loader) reload(ctx context.Context) ([]string, []func(), error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	next, stamps, err := l.loadSources()
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to reload: %w", err)
+	}
+
+	_, fingerprint, err := l.flagsNode()
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to reload: %w", err)
+	}
+	for i, source := range l.sources {
+		source.stamped = stamps[i]
+	}
+	// features that are not cacheable may read anything, e.g. files or environment variables, so the config is only
+	// known to be the same if there is nothing for them to resolve
+	if snapshot := l.snapshot.Load(); snapshot != nil && fingerprint == snapshot.flagsFingerprint &&
+		nodesEqual(l.root, next.root) && !hasUncacheableNodes(l.features, next.root) {
+		return nil, nil, nil
+	}
+
+	next.resolutions = l.resolutions
+	nextView, err := next.resolver(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to reload: %w", err)
+	}
+	if err := resolveError(nextView.root); err != nil {
+		return nil, nil, fmt.Errorf("unable to reload: %w", err)
+	}
+
+	// the snapshot that has been read, which may have been built with other flags
+	view := l.snapshot.Load()
+	if view == nil {
+		if view, err = l.resolver(ctx); err != nil {
+			return nil, nil, fmt.Errorf("unable to reload: %w", err)
+		}
+	}
+
+	var changed []string
+	diffNodes("", view.root, nextView.root, &changed)
+	notifications := l.changes(view, nextView)
+
+	l.root = next.root
+	l.anchors = next.anchors
+	l.mergeErr = nil
+	l.resolutions = next.resolutions
+	l.snapshot.Store(nextView)
+	return changed, notifications, nil
+}
+
+// loadSources loads everything loaded so far again into an empty Loader with the same options, and returns it with
+// the stamps of the sources taken before they were loaded.
+func (l *Loader) loadSources() (*Loader, []string, error) {
+	next := &Loader{
+		features:        l.features,
+		decoders:        l.decoders,
+		decodeHooks:     l.decodeHooks,
+		sensitivePaths:  l.sensitivePaths,
+		flagSets:        l.flagSets,
+		nullAsUnset:     l.nullAsUnset,
+		mergeStrategies: l.mergeStrategies,
+	}
+	stamps := make([]string, len(l.sources))
+	for i, source := range l.sources {
+		stamps[i] = stampSource(source.stamp)
+		if err := source.load(next); err != nil {
+			return nil, nil, err
+		}
+	}
+	return next, stamps, nil
+}
+
+// setMergeOption changes an option of the merge with set, and merges everything loaded so far again, notifying the
+// subscribers of the values that changed. If that fails, e.g. a file has been removed since, the config can not be
+// read until a Reload succeeds, and the error is reported to the functions registered by OnReload.
+func (l *Loader) setMergeOption(set func()) {
+	l.mu.Lock()
+	var before *Loader
+	if len(l.subscribers) > 0 {
+		before, _ = l.resolver(context.Background())
+	}
+
+	set()
+	err := l.remerge()
+	var notifications []func()
+	if err == nil && len(l.subscribers) > 0 {
+		if after, err := l.resolver(context.Background()); err == nil {
+			notifications = l.changes(before, after)
+		}
+	}
+	reloadFuncs := l.reloadFuncs
+	l.mu.Unlock()
+
+	notify(notifications)
+	if err != nil {
+		for _, fn := range reloadFuncs {
+			fn(nil, err)
+		}
+	}
+}
+
+// remerge merges everything loaded so far again, see setMergeOption.
+func (l *Loader) remerge() error {
+	l.snapshot.Store(nil)
+	next, stamps, err := l.loadSources()
+	if err != nil {
+		l.mergeErr = fmt.Errorf("unable to merge again: %w", err)
+		return l.mergeErr
+	}
+	for i, source := range l.sources {
+		source.stamped = stamps[i]
+	}
+	l.root = next.root
+	l.anchors = next.anchors
+	l.mergeErr = nil
+	return nil
+}
+
+// Watch polls everything loaded so far for changes until ctx is done, and reloads the config when it has changed. Errors
+// are reported to the functions registered by OnReload. Files are only read again once their sizes or modification
+// times have changed. The values resolved by features that are not cacheable, which may read anything, are only
+// resolved again by those reloads, or every WatchRefreshInterval if it is set.
+func (l *Loader) Watch(ctx context.Context, options ...WatchOption) error {
+	o := defaultWatchOptions()
+	for i := range options {
+		options[i].apply(o)
+	}
+
+	ticker := time.NewTicker(o.interval)
+	defer ticker.Stop()
+	refreshed := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case now := <-ticker.C:
+			refresh := o.refreshInterval > 0 && now.Sub(refreshed) >= o.refreshInterval && l.hasUncacheableNodes()
+			if refresh || l.changed() {
+				_, _ = l.Reload(ctx)
+				refreshed = now
+			}
+		}
+	}
+}
+
+// changed reports whether a source has changed since it was loaded.
+func (l *Loader) changed() bool {
+	l.mu.Lock()
+	sources := make([]source, len(l.sources))
+	for i, source := range l.sources {
+		sources[i] = *source
+	}
+	l.mu.Unlock()
+
+	for _, source := range sources {
+		if source.stamp == nil {
+			continue
+		}
+		stamp, err := source.stamp()
+		if err != nil || stamp != source.stamped {
+			return true
+		}
+	}
+	return false
+}
+
+// hasUncacheableNodes reports whether the config has values resolved by features that are not cacheable.
+func (l *Loader) hasUncacheableNodes() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return hasUncacheableNodes(l.features, l.root)
+}
+
+// hasUncacheableNodes reports whether there are nodes under node resolved by features that are not cacheable.
+func hasUncacheableNodes(features []Feature, node *Node) bool {
+	if node == nil {
+		return false
+	}
+	if node.style&yaml.TaggedStyle != 0 {
+		for _, feature := range features {
+			if feature.Name() == node.tag {
+				if !cacheable(feature) {
+					return true
+				}
+				break
+			}
+		}
+	}
+	for _, child := range node.mappingNodes {
+		if hasUncacheableNodes(features, child) {
+			return true
+		}
+	}
+	for _, child := range node.sequenceNodes {
+		if hasUncacheableNodes(features, child) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolvedValue returns the value a node is resolved to.
+func resolvedValue(n *Node) *Node {
+	for n != nil && n.resolved && n.resolvedNode != nil {
+		n = n.resolvedNode
+	}
+	return n
+}
+
+// Diff returns the changes of the resolved values from l to other, in path order, e.g. to compare two profiles. A whole
+// mapping or sequence is only reported when it is added, removed or replaced by a value of another kind.
+func (l *Loader) Diff(ctx context.Context, other *Loader) ([]Change, error) {
+	a, err := l.current(ctx)
+	if err != nil {
+		return nil, err
+	}
+	b, err := other.current(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := a.lookup(""); err != nil {
+		return nil, err
+	}
+	if _, err := b.lookup(""); err != nil {
+		return nil, err
+	}
+
+	var changed []string
+	diffNodes("", a.root, b.root, &changed)
+	changes := make([]Change, 0, len(changed))
+	for _, path := range changed {
+		change := Change{Path: path}
+		if node, _ := a.lookupRaw(path); node != nil {
+			change.Old = resolvedValue(node)
+		}
+		if node, _ := b.lookupRaw(path); node != nil {
+			change.New = resolvedValue(node)
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// diffNodes appends the dot paths under path whose resolved values differ between a and b, a whole mapping or
+// sequence is only reported when its kind has changed.
+func diffNodes(path string, a, b *Node, changed *[]string) {
+	a, b = resolvedValue(a), resolvedValue(b)
+	switch {
+	case a == nil && b == nil:
+		return
+	case a == nil || b == nil || a.kind != b.kind:
+		*changed = append(*changed, path)
+		return
+	}
+
+	switch a.kind {
+	case yaml.MappingNode:
+		keys := make([]string, 0, len(a.mappingNodes)+len(b.mappingNodes))
+		for key := range a.mappingNodes {
+			keys = append(keys, key)
+		}
+		for key := range b.mappingNodes {
+			if _, ok := a.mappingNodes[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			childPath := key
+			if path != "" {
+				childPath = path + "." + key
+			}
+			diffNodes(childPath, a.mappingNodes[key], b.mappingNodes[key], changed)
+		}
+	case yaml.SequenceNode:
+		for i := 0; i < len(a.sequenceNodes) || i < len(b.sequenceNodes); i++ {
+			var childA, childB *Node
+			if i < len(a.sequenceNodes) {
+				childA = a.sequenceNodes[i]
+			}
+			if i < len(b.sequenceNodes) {
+				childB = b.sequenceNodes[i]
+			}
+			diffNodes(fmt.Sprintf("%s[%d]", path, i), childA, childB, changed)
+		}
+	default:
+		if a.value != b.value || a.tag != b.tag {
+			*changed = append(*changed, path)
+		}
+	}
+}
+
+// nodesEqual reports whether two trees that have not been resolved are the same, positions and comments included.
+func nodesEqual(a, b *Node) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	if a.kind != b.kind || a.style != b.style || a.value != b.value || a.tag != b.tag || a.anchor != b.anchor ||
+		a.headComment != b.headComment || a.lineComment != b.lineComment || a.footComment != b.footComment ||
+		a.keyHeadComment != b.keyHeadComment || a.keyLineComment != b.keyLineComment ||
+		a.keyFootComment != b.keyFootComment || a.line != b.line || a.column != b.column ||
+		a.filepath != b.filepath || a.source != b.source || a.sparse != b.sparse ||
+		len(a.mappingNodes) != len(b.mappingNodes) || len(a.sequenceNodes) != len(b.sequenceNodes) {
+		return false
+	}
+
+	for key, child := range a.mappingNodes {
+		if !nodesEqual(child, b.mappingNodes[key]) {
+			return false
+		}
+	}
+	for i := range a.sequenceNodes {
+		if !nodesEqual(a.sequenceNodes[i], b.sequenceNodes[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,256 @@
+package gofigure
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/psanford/memfs"
+)
+
+var refFeature = FeatureFunc("!ref", func(ctx context.Context, loader *Loader, node *Node) (*Node, error) {
+	result, err := loader.GetNode(ctx, node.Value())
+	if err == nil && result == nil {
+		err = ErrPathNotFound
+	}
+	return result, err
+})
+
+// cacheableFeature makes the results of a feature reusable.
+type cacheableFeature struct {
+	Feature
+}
+
+func (cacheableFeature) Cacheable() bool {
+	return true
+}
+
+var _ = Describe("Reload", func() {
+	var fs *memfs.FS
+	var loader *Loader
+	var notified [][]string
+	var notifiedErrors []error
+
+	BeforeEach(func() {
+		fs = memfs.New()
+		Expect(fs.MkdirAll("storage", 0755)).To(BeNil())
+		Expect(fs.WriteFile("app.yaml", []byte(`env: dev
+port: 8080
+listen: !ref app.port`), 0644)).To(BeNil())
+		Expect(fs.WriteFile("storage/db.yaml", []byte(`host: localhost`), 0644)).To(BeNil())
+
+		notified = nil
+		notifiedErrors = nil
+		loader = New().WithFeatures(refFeature).OnReload(func(changed []string, err error) {
+			notified = append(notified, changed)
+			notifiedErrors = append(notifiedErrors, err)
+		})
+		Expect(loader.LoadFS(fs)).To(BeNil())
+		Expect(loader.Load("storage/db.yaml", []byte(`port: 3306`))).To(BeNil())
+	})
+
+	It("should reload changed files", func() {
+		var port int
+		Expect(loader.Get(context.Background(), "app.listen", &port)).To(BeNil())
+		Expect(port).To(Equal(8080))
+
+		Expect(fs.WriteFile("app.yaml", []byte(`env: dev
+port: 80
+listen: !ref app.port`), 0644)).To(BeNil())
+		Expect(fs.WriteFile("storage/cache.yaml", []byte(`host: localhost`), 0644)).To(BeNil())
+
+		changed, err := loader.Reload(context.Background())
+		Expect(err).To(BeNil())
+		Expect(changed).To(Equal([]string{"app.listen", "app.port", "storage.cache"}))
+		Expect(notified).To(Equal([][]string{changed}))
+		Expect(notifiedErrors).To(Equal([]error{nil}))
+
+		Expect(loader.Get(context.Background(), "app.listen", &port)).To(BeNil())
+		Expect(port).To(Equal(80))
+		Expect(loader.Get(context.Background(), "storage.db.port", &port)).To(BeNil())
+		Expect(port).To(Equal(3306))
+	})
+
+	It("should not notify when nothing has changed", func() {
+		changed, err := loader.Reload(context.Background())
+		Expect(err).To(BeNil())
+		Expect(changed).To(BeEmpty())
+
+		// comments do not change any value
+		Expect(fs.WriteFile("storage/db.yaml", []byte(`# the database
+host: localhost`), 0644)).To(BeNil())
+		changed, err = loader.Reload(context.Background())
+		Expect(err).To(BeNil())
+		Expect(changed).To(BeEmpty())
+		Expect(notified).To(BeEmpty())
+	})
+
+	It("should resolve features again when the files are the same", func() {
+		host := "localhost"
+		loader := New().WithFeatures(FeatureFunc("!host", func(ctx context.Context, loader *Loader, node *Node) (*Node, error) {
+			return NewScalarNode(host), nil
+		}))
+		Expect(loader.Load("app.yaml", []byte(`host: !host`))).To(BeNil())
+		var value string
+		Expect(loader.Get(context.Background(), "app.host", &value)).To(BeNil())
+		Expect(value).To(Equal("localhost"))
+
+		host = "db.internal"
+		changed, err := loader.Reload(context.Background())
+		Expect(err).To(BeNil())
+		Expect(changed).To(Equal([]string{"app.host"}))
+		Expect(loader.Get(context.Background(), "app.host", &value)).To(BeNil())
+		Expect(value).To(Equal("db.internal"))
+	})
+
+	It("should keep the last good config when the files fail to parse", func() {
+		Expect(fs.WriteFile("app.yaml", []byte(`env: [`), 0644)).To(BeNil())
+		changed, err := loader.Reload(context.Background())
+		Expect(err).To(MatchError(ErrConfigParseError))
+		Expect(changed).To(BeEmpty())
+		Expect(notifiedErrors).To(HaveLen(1))
+		Expect(notifiedErrors[0]).To(Equal(err))
+
+		var env string
+		Expect(loader.Get(context.Background(), "app.env", &env)).To(BeNil())
+		Expect(env).To(Equal("dev"))
+	})
+
+	It("should keep the last good config when the config fails to resolve", func() {
+		failing := FeatureFunc("!fail", func(ctx context.Context, loader *Loader, node *Node) (*Node, error) {
+			return nil, ErrPathNotFound
+		})
+		loader.WithFeatures(failing)
+		Expect(fs.WriteFile("app.yaml", []byte(`env: !fail prod`), 0644)).To(BeNil())
+		_, err := loader.Reload(context.Background())
+		Expect(err).To(MatchError(ErrPathNotFound))
+
+		var env string
+		Expect(loader.Get(context.Background(), "app.env", &env)).To(BeNil())
+		Expect(env).To(Equal("dev"))
+	})
+})
+
+var _ = Describe("Watch", func() {
+	It("should reload when files change", func() {
+		dir, err := os.MkdirTemp("", "gofigure")
+		Expect(err).To(BeNil())
+		defer os.RemoveAll(dir)
+		Expect(os.WriteFile(filepath.Join(dir, "app.yaml"), []byte(`env: dev`), 0644)).To(BeNil())
+
+		changes := make(chan []string, 1)
+		loader := New().OnReload(func(changed []string, err error) {
+			changes <- changed
+		})
+		Expect(loader.LoadFS(os.DirFS(dir))).To(BeNil())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error)
+		go func() {
+			done <- loader.Watch(ctx, WatchInterval(10*time.Millisecond))
+		}()
+
+		Expect(os.WriteFile(filepath.Join(dir, "app.yaml"), []byte(`env: prod`), 0644)).To(BeNil())
+		Eventually(changes).Should(Receive(Equal([]string{"app.env"})))
+
+		var env string
+		Expect(loader.Get(context.Background(), "app.env", &env)).To(BeNil())
+		Expect(env).To(Equal("prod"))
+
+		cancel()
+		Eventually(done).Should(Receive(MatchError(context.Canceled)))
+	})
+
+	It("should stamp the files instead of reading them", func() {
+		fs := memfs.New()
+		Expect(fs.WriteFile("app.yaml", []byte(`env: dev`), 0644)).To(BeNil())
+		loader := New()
+		Expect(loader.Load("static.yaml", []byte(`env: dev`))).To(BeNil())
+		Expect(loader.LoadFS(fs)).To(BeNil())
+		Expect(loader.LoadEnv(EnvEnviron([]string{"APP__ENV=dev"}))).To(BeNil())
+
+		Expect(loader.changed()).To(BeFalse())
+
+		Expect(fs.WriteFile("app.yaml", []byte(`env: prod`), 0644)).To(BeNil())
+		Expect(loader.changed()).To(BeTrue())
+		_, err := loader.Reload(context.Background())
+		Expect(err).To(BeNil())
+		Expect(loader.changed()).To(BeFalse())
+
+		Expect(fs.WriteFile("cache.yaml", []byte(`env: prod`), 0644)).To(BeNil())
+		Expect(loader.changed()).To(BeTrue())
+		_, err = loader.Reload(context.Background())
+		Expect(err).To(BeNil())
+
+		Expect(loader.Load("tag.yaml", []byte(`port: !unknown app.port`))).To(BeNil())
+		Expect(loader.changed()).To(BeFalse())
+		loader.WithFeatures(cacheableFeature{refFeature})
+		Expect(loader.Load("ref.yaml", []byte(`port: !ref app.port`))).To(BeNil())
+		Expect(loader.changed()).To(BeFalse())
+		loader.WithFeatures(FeatureFunc("!host", func(ctx context.Context, loader *Loader, node *Node) (*Node, error) {
+			return NewScalarNode("localhost"), nil
+		}))
+		Expect(loader.Load("host.yaml", []byte(`host: !host`))).To(BeNil())
+		Expect(loader.changed()).To(BeFalse())
+	})
+
+	It("should only resolve features that are not cacheable again every refresh interval", func() {
+		var resolved atomic.Int32
+		host := FeatureFunc("!host", func(ctx context.Context, loader *Loader, node *Node) (*Node, error) {
+			resolved.Add(1)
+			return NewScalarNode("localhost"), nil
+		})
+		watch := func(options ...WatchOption) int32 {
+			loader := New().WithFeatures(host)
+			Expect(loader.Load("app.yaml", []byte(`host: !host`))).To(BeNil())
+			var value string
+			Expect(loader.Get(context.Background(), "app.host", &value)).To(BeNil())
+			resolved.Store(0)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			Expect(loader.Watch(ctx, append(options, WatchInterval(5*time.Millisecond))...)).To(MatchError(context.DeadlineExceeded))
+			return resolved.Load()
+		}
+
+		Expect(watch()).To(BeZero())
+		Expect(watch(WatchRefreshInterval(20 * time.Millisecond))).To(BeNumerically(">=", 2))
+	})
+})
+
+var _ = Describe("Diff", func() {
+	It("should diff the resolved values of two loaders", func() {
+		staging := New().WithFeatures(refFeature)
+		Expect(staging.Load("app.yaml", []byte(`port: 8080
+listen: !ref app.port
+zone: a
+servers: [a]`))).To(BeNil())
+		prod := New().WithFeatures(refFeature)
+		Expect(prod.Load("app.yaml", []byte(`port: 80
+listen: !ref app.port
+debug: false
+servers: [a, b]`))).To(BeNil())
+
+		changes, err := staging.Diff(context.Background(), prod)
+		Expect(err).To(BeNil())
+		paths := make([]string, len(changes))
+		for i, change := range changes {
+			paths[i] = change.Path
+		}
+		Expect(paths).To(Equal([]string{"app.debug", "app.listen", "app.port", "app.servers[1]", "app.zone"}))
+		Expect(changes[0].Old).To(BeNil())
+		Expect(changes[0].New.Value()).To(Equal("false"))
+		Expect(changes[1].Old.Value()).To(Equal("8080"))
+		Expect(changes[1].New.Value()).To(Equal("80"))
+		Expect(changes[4].Old.Value()).To(Equal("a"))
+		Expect(changes[4].New).To(BeNil())
+
+		Expect(prod.Load("broken.yaml", []byte(`value: !ref missing`))).To(BeNil())
+		_, err = staging.Diff(context.Background(), prod)
+		Expect(err).To(MatchError(ErrPathNotFound))
+	})
+})
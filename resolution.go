@@ -0,0 +1,85 @@
+package gofigure
+
+import (
+	"context"
+)
+
+// resolution is the result of a feature resolving a node, along with what it depends on, so it can be reused by the
+// next snapshot as long as the node and the values it depends on are unchanged.
+type resolution struct {
+	node   *Node
+	deps   []*resolutionDep
+	result *Node
+	// set if the result cannot be reused, e.g. the feature failed to get a value or loaded files
+	volatile bool
+}
+
+type resolutionDep struct {
+	path  string
+	value *Node
+}
+
+func (r *resolution) depend(path string, value *Node, err error) {
+	if err != nil {
+		r.volatile = true
+		return
+	}
+	r.deps = append(r.deps, &resolutionDep{path: path, value: value})
+}
+
+// valid reports whether the resolution still holds for node in view.
+func (r *resolution) valid(ctx context.Context, view *Loader, node *Node) bool {
+	if !nodesEqual(r.node, node) {
+		return false
+	}
+
+	// the children are resolved before the node, so their values are inputs as well
+	var changed []string
+	for key, child := range node.mappingNodes {
+		diffNodes(key, r.node.mappingNodes[key], child, &changed)
+	}
+	for i, child := range node.sequenceNodes {
+		diffNodes("", r.node.sequenceNodes[i], child, &changed)
+	}
+	if len(changed) > 0 {
+		return false
+	}
+
+	for _, dep := range r.deps {
+		value, err := view.getNode(ctx, dep.path)
+		if err != nil {
+			return false
+		}
+		diffNodes(dep.path, dep.value, value, &changed)
+		if len(changed) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// resolveFeature resolves a tagged node with feature, reusing the result of the previous snapshot if the feature is
+// cacheable and the node and the values it depends on are unchanged. The values a feature depends on are the ones it
+// gets from the loader.
+func (l *Loader) resolveFeature(ctx context.Context, feature Feature, node *Node) (*Node, error) {
+	keypath := node.Keypath()
+	if r := l.previousResolutions[keypath]; r != nil && r.valid(ctx, l, node) {
+		l.resolutions[keypath] = r
+		return r.result.clone(), nil
+	}
+
+	r := &resolution{node: node}
+	l.resolving = append(l.resolving, r)
+	loaded := len(l.loaded)
+	result, err := feature.Resolve(ctx, l, node)
+	l.resolving = l.resolving[:len(l.resolving)-1]
+	if err != nil {
+		return nil, err
+	}
+
+	if cacheable(feature) && !r.volatile && len(l.loaded) == loaded {
+		r.result = result
+		l.resolutions[keypath] = r
+	}
+	return result, nil
+}
@@ -0,0 +1,121 @@
+package gofigure
+
+import (
+	"context"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/psanford/memfs"
+)
+
+var _ = Describe("Resolution", func() {
+	var resolved map[string]int
+	var loader *Loader
+
+	BeforeEach(func() {
+		resolved = map[string]int{}
+		// !join concatenates the values at the paths it is given
+		join := FeatureFunc("!join", func(ctx context.Context, loader *Loader, node *Node) (*Node, error) {
+			resolved[node.Keypath()]++
+			var values []string
+			for _, path := range strings.Fields(node.Value()) {
+				value, err := loader.GetNode(ctx, path)
+				if err != nil {
+					return nil, err
+				}
+				values = append(values, value.Value())
+			}
+			return NewScalarNode(strings.Join(values, ":")), nil
+		})
+		upper := FeatureFunc("!upper", func(ctx context.Context, loader *Loader, node *Node) (*Node, error) {
+			resolved[node.Keypath()]++
+			value, err := node.GetMappingChild("value")
+			if err != nil {
+				return nil, err
+			}
+			return NewScalarNode(strings.ToUpper(value.Value())), nil
+		})
+
+		loader = New().WithFeatures(cacheableFeature{join}, cacheableFeature{upper})
+		Expect(loader.Load("app.yaml", []byte(`host: localhost
+port: 8080
+listen: !join app.host app.port
+url: !join app.listen
+name: !upper
+  value: !join app.host`))).To(BeNil())
+		Expect(loader.Load("storage/db.yaml", []byte(`host: localhost`))).To(BeNil())
+	})
+
+	get := func(path string) string {
+		var value string
+		Expect(loader.Get(context.Background(), path, &value)).To(BeNil())
+		return value
+	}
+
+	It("should reuse resolutions whose dependencies are unchanged", func() {
+		Expect(get("app.url")).To(Equal("localhost:8080"))
+		Expect(resolved).To(Equal(map[string]int{"app.listen": 1, "app.url": 1, "app.name": 1, "app.name.value": 1}))
+
+		Expect(loader.Load("storage/db.yaml", []byte(`host: remote-address`))).To(BeNil())
+		Expect(get("app.url")).To(Equal("localhost:8080"))
+		Expect(resolved).To(Equal(map[string]int{"app.listen": 1, "app.url": 1, "app.name": 1, "app.name.value": 1}))
+	})
+
+	It("should resolve again the values depending on changed values", func() {
+		Expect(get("app.url")).To(Equal("localhost:8080"))
+
+		Expect(loader.Load("app.yaml", []byte(`port: 80`))).To(BeNil())
+		Expect(get("app.listen")).To(Equal("localhost:80"))
+		Expect(get("app.url")).To(Equal("localhost:80"))
+		Expect(get("app.name")).To(Equal("LOCALHOST"))
+		Expect(resolved).To(Equal(map[string]int{"app.listen": 2, "app.url": 2, "app.name": 1, "app.name.value": 1}))
+
+		Expect(loader.Load("app.yaml", []byte(`host: remote-address`))).To(BeNil())
+		Expect(get("app.url")).To(Equal("remote-address:80"))
+		Expect(get("app.name")).To(Equal("REMOTE-ADDRESS"))
+		Expect(resolved).To(Equal(map[string]int{"app.listen": 3, "app.url": 3, "app.name": 2, "app.name.value": 2}))
+	})
+
+	It("should resolve again the values that have changed", func() {
+		Expect(get("app.url")).To(Equal("localhost:8080"))
+
+		Expect(loader.Load("app.yaml", []byte(`url: !join app.host`))).To(BeNil())
+		Expect(get("app.url")).To(Equal("localhost"))
+		Expect(resolved["app.url"]).To(Equal(2))
+		Expect(resolved["app.listen"]).To(Equal(1))
+	})
+
+	It("should not reuse the resolutions of features that are not cacheable", func() {
+		hosts := []string{"localhost", "remote-address"}
+		loader.WithFeatures(FeatureFunc("!host", func(ctx context.Context, loader *Loader, node *Node) (*Node, error) {
+			return NewScalarNode(hosts[0]), nil
+		}))
+		Expect(loader.Load("storage/db.yaml", []byte(`host: !host`))).To(BeNil())
+		Expect(get("storage.db.host")).To(Equal("localhost"))
+
+		hosts = hosts[1:]
+		Expect(loader.Load("storage/db.yaml", []byte(`port: 3306`))).To(BeNil())
+		Expect(get("storage.db.host")).To(Equal("remote-address"))
+		Expect(get("app.url")).To(Equal("localhost:8080"))
+		Expect(resolved["app.url"]).To(Equal(1))
+	})
+
+	It("should reuse the resolutions of cacheable features on reload", func() {
+		fs := memfs.New()
+		Expect(fs.WriteFile("cache.yaml", []byte(`host: localhost`), 0644)).To(BeNil())
+		Expect(loader.LoadFS(fs)).To(BeNil())
+		Expect(get("app.url")).To(Equal("localhost:8080"))
+
+		Expect(fs.WriteFile("cache.yaml", []byte(`host: remote-address`), 0644)).To(BeNil())
+		_, err := loader.Reload(context.Background())
+		Expect(err).To(BeNil())
+		Expect(resolved).To(Equal(map[string]int{"app.listen": 1, "app.url": 1, "app.name": 1, "app.name.value": 1}))
+
+		Expect(fs.WriteFile("app.yaml", []byte(`host: remote-address`), 0644)).To(BeNil())
+		_, err = loader.Reload(context.Background())
+		Expect(err).To(BeNil())
+		Expect(get("app.url")).To(Equal("remote-address:8080"))
+		Expect(resolved).To(Equal(map[string]int{"app.listen": 2, "app.url": 2, "app.name": 2, "app.name.value": 2}))
+	})
+})
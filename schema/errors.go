@@ -0,0 +1,57 @@
+package schema
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Violation is a value that does not satisfy a keyword of the schema.
+type Violation struct {
+	// Path is the dot path of the value, e.g. app.servers[0].port.
+	Path string
+	// Source, Line and Column are where the value was set, see gofigure.Node.Source. Line and Column are zero for
+	// values not loaded from files.
+	Source string
+	Line   int
+	Column int
+	// Keyword is the keyword of the schema that is not satisfied, at SchemaLocation, e.g. schema.json#/properties/port.
+	Keyword        string
+	SchemaLocation string
+	Message        string
+}
+
+func (v Violation) Error() string {
+	position := v.Source
+	if v.Line > 0 {
+		position = fmt.Sprintf("%s:%d:%d", v.Source, v.Line, v.Column)
+	}
+	path := v.Path
+	if path == "" {
+		path = "(root)"
+	}
+	if position == "" {
+		return fmt.Sprintf("%s: %s", path, v.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", position, path, v.Message)
+}
+
+// ValidationError is every violation of a schema by a config, in the order they are found, which follows the config.
+type ValidationError struct {
+	Violations []Violation
+}
+
+func (e *ValidationError) Error() string {
+	lines := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		lines[i] = v.Error()
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (e *ValidationError) Unwrap() []error {
+	errs := make([]error, len(e.Violations))
+	for i, v := range e.Violations {
+		errs[i] = v
+	}
+	return errs
+}
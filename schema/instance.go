@@ -0,0 +1,164 @@
+package schema
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/joesonw/gofigure"
+)
+
+// instance is a resolved value to validate, typed the JSON way, along with where it was set.
+type instance struct {
+	path   string
+	source string
+	line   int
+	column int
+
+	// null, boolean, number, string, object or array
+	kind string
+	// bool, string or a number for scalars
+	value any
+
+	keys       []string
+	properties map[string]*instance
+	items      []*instance
+}
+
+func newInstance(node *gofigure.Node, path string) *instance {
+	in := &instance{
+		path:   path,
+		source: node.Source(),
+		line:   node.Line(),
+		column: node.Column(),
+	}
+
+	value := node.Resolved()
+	switch value.Kind() {
+	case yaml.MappingNode:
+		in.kind = "object"
+		in.properties = map[string]*instance{}
+		for _, key := range value.MappingKeys() {
+			child, _ := value.GetMappingChild(key)
+			if child == nil {
+				continue
+			}
+			in.keys = append(in.keys, key)
+			in.properties[key] = newInstance(child, childPath(path, key))
+		}
+	case yaml.SequenceNode:
+		in.kind = "array"
+		for i := 0; ; i++ {
+			child, _ := value.GetSequenceChild(i)
+			if child == nil {
+				break
+			}
+			in.items = append(in.items, newInstance(child, fmt.Sprintf("%s[%d]", path, i)))
+		}
+	default:
+		var v any
+		if err := value.ToYAMLNode().Decode(&v); err != nil {
+			v = value.Value()
+		}
+		in.setScalar(v, value.Value())
+	}
+	return in
+}
+
+// setScalar sets the value of a scalar decoded from YAML, text is the value as it was written.
+func (in *instance) setScalar(v any, text string) {
+	switch v := v.(type) {
+	case nil:
+		in.kind = "null"
+	case bool:
+		in.kind = "boolean"
+		in.value = v
+	case string:
+		in.kind = "string"
+		in.value = v
+	case time.Time:
+		in.kind = "string"
+		in.value = text
+	default:
+		if _, ok := toFloat(v); !ok {
+			in.kind = "string"
+			in.value = text
+			return
+		}
+		in.kind = "number"
+		in.value = v
+	}
+}
+
+// at returns the instance at a JSON pointer, split into tokens, or the closest one there is.
+func (in *instance) at(tokens []string) *instance {
+	for _, token := range tokens {
+		var child *instance
+		switch in.kind {
+		case "object":
+			child = in.properties[token]
+		case "array":
+			if i, err := strconv.Atoi(token); err == nil && i >= 0 && i < len(in.items) {
+				child = in.items[i]
+			}
+		}
+		if child == nil {
+			break
+		}
+		in = child
+	}
+	return in
+}
+
+// walk calls fn with in and every instance under it, in order.
+func (in *instance) walk(fn func(in *instance)) {
+	fn(in)
+	for _, key := range in.keys {
+		in.properties[key].walk(fn)
+	}
+	for _, item := range in.items {
+		item.walk(fn)
+	}
+}
+
+// json returns the value the way it is decoded from JSON, to compare it with the values of a schema.
+func (in *instance) json() any {
+	switch in.kind {
+	case "object":
+		m := make(map[string]any, len(in.keys))
+		for _, key := range in.keys {
+			m[key] = in.properties[key].json()
+		}
+		return m
+	case "array":
+		a := make([]any, len(in.items))
+		for i, item := range in.items {
+			a[i] = item.json()
+		}
+		return a
+	}
+	return in.value
+}
+
+func childPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func toFloat(v any) (float64, bool) {
+	switch v := v.(type) {
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
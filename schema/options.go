@@ -0,0 +1,48 @@
+package schema
+
+type compileOptions struct {
+	uri          string
+	resources    map[string][]byte
+	assertFormat bool
+}
+
+func defaultCompileOptions() *compileOptions {
+	return &compileOptions{
+		uri:       "schema.json",
+		resources: map[string][]byte{},
+	}
+}
+
+type Option interface {
+	apply(*compileOptions)
+}
+
+type optionFunc func(*compileOptions)
+
+func (f optionFunc) apply(o *compileOptions) {
+	f(o)
+}
+
+// URI sets the URI of the schema, "schema.json" by default, which relative references are resolved against unless
+// the schema sets its own $id.
+func URI(uri string) Option {
+	return optionFunc(func(o *compileOptions) {
+		o.uri = uri
+	})
+}
+
+// Resource makes the schema document contents available to $ref at uri, e.g. for a definitions file shared by several
+// schemas. Schemas are never fetched.
+func Resource(uri string, contents []byte) Option {
+	return optionFunc(func(o *compileOptions) {
+		o.resources[uri] = contents
+	})
+}
+
+// AssertFormat makes "format" an assertion rather than an annotation, for the formats defined by draft 2020-12. Unknown
+// formats are always ignored.
+func AssertFormat() Option {
+	return optionFunc(func(o *compileOptions) {
+		o.assertFormat = true
+	})
+}
@@ -0,0 +1,130 @@
+// Package schema validates resolved configs against JSON Schemas (draft 2020-12), reporting every violation with the
+// dot path of the value and the file, line and column it was set at. Schemas are compiled and validated by
+// github.com/santhosh-tekuri/jsonschema, which passes the JSON-Schema-Test-Suite.
+package schema
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"gopkg.in/yaml.v3"
+
+	"github.com/joesonw/gofigure"
+)
+
+// Schema is a compiled JSON Schema. It is safe for concurrent use.
+//
+// Every keyword of draft 2020-12 is supported, including $dynamicRef. Patterns are Go regular expressions.
+type Schema struct {
+	schema *jsonschema.Schema
+}
+
+// Compile compiles a schema written in JSON or YAML.
+func Compile(contents []byte, options ...Option) (*Schema, error) {
+	o := defaultCompileOptions()
+	for i := range options {
+		options[i].apply(o)
+	}
+
+	c := jsonschema.NewCompiler()
+	c.DefaultDraft(jsonschema.Draft2020)
+	c.UseLoader(noLoader{})
+	if o.assertFormat {
+		c.AssertFormat()
+	}
+	for uri, resource := range o.resources {
+		if _, err := addDocument(c, uri, resource); err != nil {
+			return nil, err
+		}
+	}
+	location, err := addDocument(c, o.uri, contents)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := c.Compile(location)
+	if err != nil {
+		return nil, errors.New(displayLocation(err.Error()))
+	}
+	return &Schema{schema: s}, nil
+}
+
+// Validate validates a resolved node, e.g. as returned by Loader.GetNode, and returns a *ValidationError with every
+// violation if it is not valid.
+func (s *Schema) Validate(node *gofigure.Node) error {
+	return s.validate(newInstance(node, node.Keypath()))
+}
+
+// ValidatePath validates the resolved value at path of loader, the whole config if path is empty.
+func (s *Schema) ValidatePath(ctx context.Context, loader *gofigure.Loader, path string) error {
+	node, err := loader.GetNode(ctx, path)
+	if err != nil {
+		return err
+	}
+	if node == nil {
+		return fmt.Errorf("%s: %w", path, gofigure.ErrPathNotFound)
+	}
+	return s.validate(newInstance(node, path))
+}
+
+func (s *Schema) validate(in *instance) error {
+	err := s.schema.Validate(in.json())
+	if err == nil {
+		return nil
+	}
+	var validationError *jsonschema.ValidationError
+	if !errors.As(err, &validationError) {
+		return err
+	}
+
+	var violations []Violation
+	collectViolations(validationError, in, &violations)
+	// in the order of the config
+	order := map[string]int{}
+	in.walk(func(in *instance) {
+		order[in.path] = len(order)
+	})
+	sort.SliceStable(violations, func(i, j int) bool {
+		return order[violations[i].Path] < order[violations[j].Path]
+	})
+	return &ValidationError{Violations: violations}
+}
+
+// localRoot is the base of relative schema URIs, so they are not taken for file paths. It is left out of the locations
+// in messages.
+const localRoot = "gofigure-schema:///"
+
+func displayLocation(location string) string {
+	return strings.ReplaceAll(location, localRoot, "")
+}
+
+// addDocument adds a schema document written in JSON or YAML at uri, and returns its absolute URI.
+func addDocument(c *jsonschema.Compiler, uri string, contents []byte) (string, error) {
+	base, _ := url.Parse(localRoot)
+	u, err := base.Parse(uri)
+	if err != nil {
+		return "", fmt.Errorf("invalid schema URI %q: %w", uri, err)
+	}
+	u.Fragment, u.RawFragment = "", ""
+
+	var document any
+	if err := yaml.Unmarshal(contents, &document); err != nil {
+		return "", fmt.Errorf("unable to parse schema %q: %w", displayLocation(u.String()), err)
+	}
+	if err := c.AddResource(u.String(), document); err != nil {
+		return "", errors.New(displayLocation(err.Error()))
+	}
+	return u.String(), nil
+}
+
+// noLoader refuses to load the schemas that are not added as resources, schemas are never fetched.
+type noLoader struct{}
+
+func (noLoader) Load(url string) (any, error) {
+	return nil, fmt.Errorf("unable to resolve %q, schemas are never fetched", displayLocation(url))
+}
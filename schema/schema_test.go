@@ -0,0 +1,75 @@
+package schema_test
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/joesonw/gofigure"
+	"github.com/joesonw/gofigure/feature"
+	"github.com/joesonw/gofigure/schema"
+)
+
+var _ = Describe("Schema", func() {
+	It("should report every violation with its position", func() {
+		loader := gofigure.New().WithFeatures(feature.Reference())
+		Expect(loader.Load("config/app.yaml", []byte(`name: gofigure
+port: 8080
+listen: !ref config.app.port
+servers:
+  - host: a
+    port: 1
+  - port: -1
+`))).To(BeNil())
+		Expect(loader.Load("config/app.toml", []byte(`port = 70000`))).To(BeNil())
+
+		s, err := schema.Compile([]byte(`
+type: object
+required: [name, port, debug]
+properties:
+  name: {type: string}
+  port: {$ref: "#/$defs/port"}
+  listen: {type: string}
+  servers:
+    type: array
+    items:
+      type: object
+      required: [host]
+      properties:
+        port: {$ref: "#/$defs/port"}
+$defs:
+  port: {type: integer, minimum: 1, maximum: 65535}
+`))
+		Expect(err).To(BeNil())
+
+		err = s.ValidatePath(context.Background(), loader, "config.app")
+		var validationError *schema.ValidationError
+		Expect(errors.As(err, &validationError)).To(BeTrue())
+		Expect(validationError.Violations).To(HaveLen(5))
+		Expect(validationError.Violations[0]).To(Equal(schema.Violation{
+			Path:           "config.app",
+			Source:         "config/app.yaml",
+			Line:           1,
+			Column:         1,
+			Keyword:        "required",
+			SchemaLocation: "schema.json#/required",
+			Message:        `missing required property "debug"`,
+		}))
+		Expect(err.Error()).To(Equal(`config/app.yaml:1:1: config.app: missing required property "debug"
+config/app.toml:1:8: config.app.port: must be <= 65535
+config/app.yaml:3:9: config.app.listen: must be of type string, not number
+config/app.yaml:7:5: config.app.servers[1]: missing required property "host"
+config/app.yaml:7:11: config.app.servers[1].port: must be >= 1`))
+
+		Expect(s.ValidatePath(context.Background(), loader, "config.missing")).To(MatchError(gofigure.ErrPathNotFound))
+	})
+
+	It("should validate nodes", func() {
+		s, err := schema.Compile([]byte(`{"type": "object"}`))
+		Expect(err).To(BeNil())
+		Expect(s.Validate(node(`{a: 1}`))).To(BeNil())
+		Expect(s.Validate(node(`a`))).To(MatchError(`app.yaml:1:1: (root): must be of type object, not string`))
+	})
+})
@@ -0,0 +1,156 @@
+package schema
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/santhosh-tekuri/jsonschema/v6/kind"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+var printer = message.NewPrinter(language.English)
+
+// collectViolations adds a violation for every keyword that failed under err, the keywords that only apply other
+// schemas, e.g. $ref, properties or allOf, are reported by the keywords of those schemas that failed.
+//
+//nolint:gocyclo
+func collectViolations(err *jsonschema.ValidationError, root *instance, violations *[]Violation) {
+	switch err.ErrorKind.(type) {
+	case *kind.Schema, *kind.Group, *kind.Reference, *kind.AllOf:
+		for _, cause := range err.Causes {
+			collectViolations(cause, root, violations)
+		}
+		return
+	}
+
+	in := root.at(err.InstanceLocation)
+	keywordPath := err.ErrorKind.KeywordPath()
+	location := displayLocation(err.SchemaURL)
+	for _, token := range keywordPath {
+		location += "/" + escapePointer(token)
+	}
+	keyword := "false"
+	if len(keywordPath) > 0 {
+		keyword = keywordPath[len(keywordPath)-1]
+	}
+	fail := func(in *instance, format string, args ...any) {
+		*violations = append(*violations, Violation{
+			Path:           in.path,
+			Source:         in.source,
+			Line:           in.line,
+			Column:         in.column,
+			Keyword:        keyword,
+			SchemaLocation: location,
+			Message:        fmt.Sprintf(format, args...),
+		})
+	}
+
+	switch k := err.ErrorKind.(type) {
+	case *kind.FalseSchema:
+		fail(in, "no value is allowed")
+	case *kind.Type:
+		fail(in, "must be of type %s, not %s", strings.Join(k.Want, " or "), k.Got)
+	case *kind.Enum:
+		fail(in, "must be one of %s", formatValues(k.Want))
+	case *kind.Const:
+		fail(in, "must be %s", formatValue(k.Want))
+	case *kind.MultipleOf:
+		fail(in, "must be a multiple of %s", formatNumber(k.Want))
+	case *kind.Maximum:
+		fail(in, "must be <= %s", formatNumber(k.Want))
+	case *kind.ExclusiveMaximum:
+		fail(in, "must be < %s", formatNumber(k.Want))
+	case *kind.Minimum:
+		fail(in, "must be >= %s", formatNumber(k.Want))
+	case *kind.ExclusiveMinimum:
+		fail(in, "must be > %s", formatNumber(k.Want))
+	case *kind.MaxLength:
+		fail(in, "must be at most %d characters long", k.Want)
+	case *kind.MinLength:
+		fail(in, "must be at least %d characters long", k.Want)
+	case *kind.Pattern:
+		fail(in, "must match the pattern %q", k.Want)
+	case *kind.Format:
+		fail(in, "must be a valid %s", k.Want)
+	case *kind.MaxItems:
+		fail(in, "must have at most %d items", k.Want)
+	case *kind.MinItems:
+		fail(in, "must have at least %d items", k.Want)
+	case *kind.UniqueItems:
+		fail(in, "must have unique items, [%d] and [%d] are equal", k.Duplicates[0], k.Duplicates[1])
+	case *kind.Contains:
+		fail(in, "must contain at least 1 matching item, found 0")
+	case *kind.MinContains:
+		fail(in, "must contain at least %d matching items, found %d", k.Want, len(k.Got))
+	case *kind.MaxContains:
+		fail(in, "must contain at most %d matching items, found %d", k.Want, len(k.Got))
+	case *kind.MaxProperties:
+		fail(in, "must have at most %d properties", k.Want)
+	case *kind.MinProperties:
+		fail(in, "must have at least %d properties", k.Want)
+	case *kind.Required:
+		for _, name := range k.Missing {
+			fail(in, "missing required property %q", name)
+		}
+	case *kind.DependentRequired:
+		for _, name := range k.Missing {
+			fail(in, "missing property %q required by %q", name, k.Prop)
+		}
+	case *kind.AdditionalProperties:
+		for _, name := range k.Properties {
+			fail(in.at([]string{name}), "property %q is not allowed", name)
+		}
+	case *kind.PropertyNames:
+		fail(in, "property name %q is invalid", k.Property)
+	case *kind.AnyOf:
+		fail(in, "must match at least one of the %d schemas of anyOf", len(err.Causes))
+	case *kind.OneOf:
+		if len(k.Subschemas) == 0 {
+			fail(in, "must match exactly one of the schemas of oneOf, matched none")
+		} else {
+			fail(in, "must match exactly one of the schemas of oneOf, matched %v", k.Subschemas)
+		}
+	case *kind.Not:
+		keyword = "not"
+		location += "/not"
+		fail(in, "must not match the schema of not")
+	default:
+		fail(in, "%s", err.ErrorKind.LocalizedString(printer))
+	}
+}
+
+func escapePointer(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
+
+func formatNumber(r *big.Rat) string {
+	if r.IsInt() {
+		return r.Num().String()
+	}
+	f, _ := r.Float64()
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+func formatValue(v any) string {
+	switch v := v.(type) {
+	case string:
+		return strconv.Quote(v)
+	case nil:
+		return "null"
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
+
+func formatValues(values []any) string {
+	formatted := make([]string, len(values))
+	for i, value := range values {
+		formatted[i] = formatValue(value)
+	}
+	return strings.Join(formatted, ", ")
+}
@@ -0,0 +1,140 @@
+package schema_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v3"
+
+	"github.com/joesonw/gofigure"
+	"github.com/joesonw/gofigure/schema"
+)
+
+func node(contents string) *gofigure.Node {
+	var document yaml.Node
+	Expect(yaml.Unmarshal([]byte(contents), &document)).To(BeNil())
+	return gofigure.NewNode(document.Content[0], gofigure.NodeFilepath("app.yaml"))
+}
+
+// dynamicRefSchema is a strict tree, whose children are strict trees as well, through the $dynamicAnchor of tree.
+const dynamicRefSchema = `{
+	"$id": "strict-tree.json",
+	"$dynamicAnchor": "node",
+	"$ref": "tree.json",
+	"unevaluatedProperties": false,
+	"$defs": {
+		"tree": {
+			"$id": "tree.json",
+			"$dynamicAnchor": "node",
+			"type": "object",
+			"properties": {
+				"data": true,
+				"children": {"type": "array", "items": {"$dynamicRef": "#node"}}
+			}
+		}
+	}
+}`
+
+var _ = DescribeTable("Keywords", func(schemaContents, instance string, valid bool) {
+	s, err := schema.Compile([]byte(schemaContents), schema.AssertFormat())
+	Expect(err).To(BeNil())
+	err = s.Validate(node(instance))
+	if valid {
+		Expect(err).To(BeNil())
+	} else {
+		Expect(err).NotTo(BeNil())
+	}
+},
+	Entry("true", `true`, `1`, true),
+	Entry("false", `false`, `1`, false),
+	Entry("type", `{"type": "string"}`, `"1"`, true),
+	Entry("type mismatch", `{"type": "string"}`, `1`, false),
+	Entry("type integer", `{"type": "integer"}`, `1.0`, true),
+	Entry("type integer mismatch", `{"type": "integer"}`, `1.5`, false),
+	Entry("type array", `{"type": ["null", "boolean"]}`, `~`, true),
+	Entry("enum", `{"enum": [1, "a", {"b": [true]}]}`, `{b: [true]}`, true),
+	Entry("enum mismatch", `{"enum": [1, "a"]}`, `"1"`, false),
+	Entry("const", `{"const": 2}`, `2.0`, true),
+	Entry("const mismatch", `{"const": 2}`, `3`, false),
+	Entry("multipleOf", `{"multipleOf": 0.1}`, `0.3`, true),
+	Entry("multipleOf mismatch", `{"multipleOf": 2}`, `3`, false),
+	Entry("maximum", `{"maximum": 3}`, `3`, true),
+	Entry("exclusiveMaximum", `{"exclusiveMaximum": 3}`, `3`, false),
+	Entry("minimum", `{"minimum": 3}`, `2`, false),
+	Entry("exclusiveMinimum", `{"exclusiveMinimum": 3}`, `3.1`, true),
+	Entry("numbers ignore strings", `{"minimum": 3}`, `"a"`, true),
+	Entry("maxLength", `{"maxLength": 2}`, `"日本"`, true),
+	Entry("minLength", `{"minLength": 3}`, `"ab"`, false),
+	Entry("pattern", `{"pattern": "^a+$"}`, `"aab"`, false),
+	Entry("format", `{"format": "ipv4"}`, `"10.0.0.1"`, true),
+	Entry("format mismatch", `{"format": "date-time"}`, `"yesterday"`, false),
+	Entry("unknown format", `{"format": "color"}`, `"red"`, true),
+	Entry("maxItems", `{"maxItems": 1}`, `[1, 2]`, false),
+	Entry("minItems", `{"minItems": 1}`, `[]`, false),
+	Entry("uniqueItems", `{"uniqueItems": true}`, `[1, 1.0]`, false),
+	Entry("prefixItems", `{"prefixItems": [{"type": "string"}], "items": {"type": "integer"}}`, `[a, 1, 2]`, true),
+	Entry("items mismatch", `{"prefixItems": [{"type": "string"}], "items": {"type": "integer"}}`, `[a, b]`, false),
+	Entry("contains", `{"contains": {"const": 1}}`, `[2, 1]`, true),
+	Entry("contains mismatch", `{"contains": {"const": 1}}`, `[2]`, false),
+	Entry("minContains 0", `{"contains": {"const": 1}, "minContains": 0}`, `[]`, true),
+	Entry("maxContains", `{"contains": {"const": 1}, "maxContains": 1}`, `[1, 1]`, false),
+	Entry("unevaluatedItems", `{"prefixItems": [true], "contains": {"const": 2}, "unevaluatedItems": false}`, `[1, 2, 2]`, true),
+	Entry("unevaluatedItems mismatch", `{"prefixItems": [true], "unevaluatedItems": false}`, `[1, 2]`, false),
+	Entry("maxProperties", `{"maxProperties": 1}`, `{a: 1, b: 2}`, false),
+	Entry("minProperties", `{"minProperties": 1}`, `{}`, false),
+	Entry("required", `{"required": ["a"]}`, `{b: 1}`, false),
+	Entry("dependentRequired", `{"dependentRequired": {"a": ["b"]}}`, `{a: 1}`, false),
+	Entry("properties", `{"properties": {"a": {"type": "string"}}}`, `{a: 1}`, false),
+	Entry("patternProperties", `{"patternProperties": {"^x-": {"type": "string"}}}`, `{x-a: 1}`, false),
+	Entry("additionalProperties", `{"properties": {"a": true}, "patternProperties": {"^x-": true}, "additionalProperties": false}`, `{a: 1, x-b: 2}`, true),
+	Entry("additionalProperties mismatch", `{"properties": {"a": true}, "additionalProperties": false}`, `{a: 1, b: 2}`, false),
+	Entry("propertyNames", `{"propertyNames": {"maxLength": 1}}`, `{ab: 1}`, false),
+	Entry("dependentSchemas", `{"dependentSchemas": {"a": {"required": ["b"]}}}`, `{a: 1}`, false),
+	Entry("unevaluatedProperties", `{"allOf": [{"properties": {"a": true}}], "unevaluatedProperties": false}`, `{a: 1}`, true),
+	Entry("unevaluatedProperties mismatch", `{"allOf": [{"properties": {"a": true}}], "unevaluatedProperties": false}`, `{a: 1, b: 2}`, false),
+	Entry("unevaluatedProperties of anyOf", `{"anyOf": [{"properties": {"a": true}}, {"properties": {"b": true}}], "unevaluatedProperties": false}`, `{b: 1, a: 1}`, true),
+	Entry("unevaluatedProperties of failed anyOf", `{"anyOf": [{"properties": {"a": true}, "required": ["x"]}, {"required": ["b"]}], "unevaluatedProperties": false}`, `{b: 1, a: 1}`, false),
+	Entry("allOf", `{"allOf": [{"minimum": 1}, {"maximum": 2}]}`, `3`, false),
+	Entry("anyOf", `{"anyOf": [{"type": "string"}, {"minimum": 2}]}`, `3`, true),
+	Entry("anyOf mismatch", `{"anyOf": [{"type": "string"}, {"minimum": 2}]}`, `1`, false),
+	Entry("oneOf", `{"oneOf": [{"type": "integer"}, {"minimum": 2}]}`, `1`, true),
+	Entry("oneOf mismatch", `{"oneOf": [{"type": "integer"}, {"minimum": 2}]}`, `3`, false),
+	Entry("not", `{"not": {"type": "string"}}`, `"a"`, false),
+	Entry("if then", `{"if": {"minimum": 10}, "then": {"multipleOf": 10}, "else": {"maximum": 5}}`, `20`, true),
+	Entry("if then mismatch", `{"if": {"minimum": 10}, "then": {"multipleOf": 10}, "else": {"maximum": 5}}`, `15`, false),
+	Entry("if else mismatch", `{"if": {"minimum": 10}, "then": {"multipleOf": 10}, "else": {"maximum": 5}}`, `7`, false),
+	Entry("$ref", `{"$defs": {"port": {"maximum": 65535}}, "properties": {"port": {"$ref": "#/$defs/port"}}}`, `{port: 65536}`, false),
+	Entry("$ref to anchor", `{"$defs": {"port": {"$anchor": "port", "maximum": 65535}}, "$ref": "#port"}`, `65536`, false),
+	Entry("$ref with $id", `{"$defs": {"port": {"$id": "port.json", "maximum": 65535}}, "$ref": "port.json"}`, `65536`, false),
+	Entry("$dynamicRef", dynamicRefSchema, `{data: 1, children: [{data: 2}]}`, true),
+	Entry("$dynamicRef to the extended schema", dynamicRefSchema, `{data: 1, children: [{data: 2, extra: 3}]}`, false),
+	Entry("recursive $ref", `{"properties": {"child": {"$ref": "#"}}, "required": ["name"]}`, `{name: a, child: {name: b, child: {}}}`, false),
+	Entry("YAML typed values", `{"properties": {"a": {"type": "boolean"}, "b": {"type": "string"}, "c": {"type": "null"}}}`, `{a: yes, b: "yes", c: null}`, false),
+	Entry("timestamps are strings", `{"type": "string", "format": "date"}`, `2024-01-31`, true),
+)
+
+var _ = Describe("Compile", func() {
+	It("should resolve references to resources", func() {
+		s, err := schema.Compile([]byte(`{"$ref": "defs.json#/$defs/port"}`),
+			schema.Resource("defs.json", []byte(`$defs: {port: {maximum: 65535}}`)))
+		Expect(err).To(BeNil())
+		Expect(s.Validate(node(`8080`))).To(BeNil())
+		Expect(s.Validate(node(`65536`))).NotTo(BeNil())
+	})
+
+	It("should not compile invalid schemas", func() {
+		_, err := schema.Compile([]byte(`{"$ref": "#/$defs/missing"}`))
+		Expect(err).To(MatchError(`json-pointer in "schema.json#/$defs/missing" not found`))
+
+		_, err = schema.Compile([]byte(`{"properties": {"a": {"minLength": -1}}}`))
+		Expect(err).To(MatchError(ContainSubstring(`at '/properties/a/minLength': minimum: got -1, want 0`)))
+
+		_, err = schema.Compile([]byte(`{"pattern": "(?<=a)"}`))
+		Expect(err).To(MatchError(ContainSubstring(`at '/pattern': '(?<=a)' is not valid regex`)))
+
+		_, err = schema.Compile([]byte(`1`))
+		Expect(err).To(MatchError(ContainSubstring(`at '': got number, want boolean or object`)))
+
+		_, err = schema.Compile([]byte(`{"$ref": "https://example.com/schema.json"}`))
+		Expect(err).To(MatchError(ContainSubstring(`unable to resolve "https://example.com/schema.json", schemas are never fetched`)))
+	})
+})
@@ -0,0 +1,201 @@
+package gofigure
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Redacted replaces sensitive values in MarshalYAML, Export, Explanation and error messages.
+const Redacted = "******"
+
+// sensitiveAnnotation marks a value as sensitive when it is a line of the head comment of the value or its key, e.g.
+//
+//	# gofigure:sensitive
+//	password: hunter2
+const sensitiveAnnotation = "gofigure:sensitive"
+
+// hasSensitiveAnnotation reports whether the head comment of the node or its key holds the sensitive annotation.
+func hasSensitiveAnnotation(n *Node) bool {
+	for _, comment := range []string{n.keyHeadComment, n.headComment} {
+		for _, line := range strings.Split(comment, "\n") {
+			if strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "#")) == sensitiveAnnotation {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// markSensitive marks every node under a sensitive node as sensitive, sensitive is whether node is under one.
+func markSensitive(node *Node, sensitive bool) {
+	if node == nil {
+		return
+	}
+	node.sensitive = node.sensitive || sensitive
+	for _, child := range node.mappingNodes {
+		markSensitive(child, node.sensitive)
+	}
+	for _, child := range node.sequenceNodes {
+		markSensitive(child, node.sensitive)
+	}
+}
+
+// ContainsSensitive reports whether the node or any value under it is sensitive.
+func (n *Node) ContainsSensitive() bool {
+	if n == nil {
+		return false
+	}
+	if n.Sensitive() {
+		return true
+	}
+	value := resolvedValue(n)
+	for _, child := range value.mappingNodes {
+		if child.ContainsSensitive() {
+			return true
+		}
+	}
+	for _, child := range value.sequenceNodes {
+		if child.ContainsSensitive() {
+			return true
+		}
+	}
+	return false
+}
+
+// isSensitivePath reports whether the path of the node matches any of the patterns of WithSensitivePaths.
+func (l *Loader) isSensitivePath(node *Node) bool {
+	if len(l.sensitivePaths) == 0 {
+		return false
+	}
+	path := node.Keypath()
+	for _, pattern := range l.sensitivePaths {
+		if MatchPath(pattern, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactYAML replaces every scalar under node with Redacted, mapping keys excepted.
+func redactYAML(node *yaml.Node) {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		node.Value = Redacted
+		node.Tag = "!!str"
+		node.Style = 0
+	case yaml.MappingNode:
+		for i := 1; i < len(node.Content); i += 2 {
+			redactYAML(node.Content[i])
+		}
+	default:
+		for _, child := range node.Content {
+			redactYAML(child)
+		}
+	}
+}
+
+// sensitiveValues returns the resolved values of the sensitive scalars under node, sensitive is whether node is under
+// a sensitive node.
+func sensitiveValues(node *Node, sensitive bool) []string {
+	if node == nil {
+		return nil
+	}
+	sensitive = sensitive || node.Sensitive()
+	value := resolvedValue(node)
+
+	var values []string
+	// the raw values of tagged nodes that are not resolved are not their values, e.g. the key of a !secret
+	unresolved := node.style&yaml.TaggedStyle != 0 && node.resolvedNode == nil
+	if value.kind == yaml.ScalarNode && sensitive && value.value != "" && !unresolved {
+		values = append(values, value.value)
+	}
+	for _, key := range value.MappingKeys() {
+		values = append(values, sensitiveValues(value.mappingNodes[key], sensitive)...)
+	}
+	for _, child := range value.sequenceNodes {
+		values = append(values, sensitiveValues(child, sensitive)...)
+	}
+	return values
+}
+
+// redactedError is an error of a sensitive node, whose message has the sensitive values replaced with Redacted. The
+// error it wraps is not redacted.
+type redactedError struct {
+	err    error
+	values []string
+}
+
+// redactError returns err with the sensitive values of node redacted from its message.
+func redactError(node *Node, err error) error {
+	if err == nil || node == nil || !node.ContainsSensitive() {
+		return err
+	}
+	values := sensitiveValues(node, false)
+	if len(values) == 0 {
+		return err
+	}
+	return &redactedError{err: err, values: values}
+}
+
+func (e *redactedError) Error() string {
+	return redactString(e.err.Error(), e.values)
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
+
+// redactString replaces values in s with Redacted, longest first. A value is only replaced as a whole word, which is
+// neither preceded nor followed by a letter or a digit, so it is not redacted from the middle of other words.
+func redactString(s string, values []string) string {
+	values = append([]string(nil), values...)
+	sort.SliceStable(values, func(i, j int) bool {
+		return len(values[i]) > len(values[j])
+	})
+	for _, value := range values {
+		if value != "" {
+			s = redactWord(s, value)
+		}
+	}
+	return s
+}
+
+func redactWord(s, value string) string {
+	var sb strings.Builder
+	written := 0
+	for from := 0; from < len(s); {
+		i := strings.Index(s[from:], value)
+		if i < 0 {
+			break
+		}
+		start, end := from+i, from+i+len(value)
+		if isWordBoundary(s[:start], value, s[end:]) {
+			sb.WriteString(s[written:start])
+			sb.WriteString(Redacted)
+			written, from = end, end
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(s[start:])
+		from = start + size
+	}
+	sb.WriteString(s[written:])
+	return sb.String()
+}
+
+// isWordBoundary reports whether value is a whole word between before and after.
+func isWordBoundary(before, value, after string) bool {
+	first, _ := utf8.DecodeRuneInString(value)
+	last, _ := utf8.DecodeLastRuneInString(value)
+	previous, _ := utf8.DecodeLastRuneInString(before)
+	next, _ := utf8.DecodeRuneInString(after)
+	return !(isWordRune(first) && before != "" && isWordRune(previous)) &&
+		!(isWordRune(last) && after != "" && isWordRune(next))
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,219 @@
+package gofigure
+
+import (
+	"bytes"
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v3"
+)
+
+var _ = Describe("Sensitive", func() {
+	ctx := context.Background()
+
+	isSensitive := func(loader *Loader, path string) bool {
+		node, err := loader.GetNode(ctx, path)
+		Expect(err).To(BeNil())
+		Expect(node).NotTo(BeNil())
+		raw, err := loader.current(ctx)
+		Expect(err).To(BeNil())
+		rawNode, err := raw.lookupRaw(path)
+		Expect(err).To(BeNil())
+		return rawNode.Sensitive()
+	}
+
+	It("should mark values with the !sensitive tag", func() {
+		loader := New()
+		Expect(loader.Load("app.yaml", []byte(`password: !sensitive hunter2
+port: !sensitive 8080
+db: !sensitive
+  user: admin
+name: api`))).To(BeNil())
+		Expect(isSensitive(loader, "app.password")).To(BeTrue())
+		Expect(isSensitive(loader, "app.db.user")).To(BeTrue())
+		Expect(isSensitive(loader, "app.name")).To(BeFalse())
+		Expect(Get[int](ctx, loader, "app.port")).To(Equal(8080))
+	})
+
+	It("should mark values with the annotation", func() {
+		loader := New()
+		Expect(loader.Load("app.yaml", []byte(`# the password of the database
+# gofigure:sensitive
+password: hunter2
+#gofigure:sensitive
+db:
+  user: admin
+name: api`))).To(BeNil())
+		Expect(isSensitive(loader, "app.password")).To(BeTrue())
+		Expect(isSensitive(loader, "app.db.user")).To(BeTrue())
+		Expect(isSensitive(loader, "app.name")).To(BeFalse())
+	})
+
+	It("should mark values matching the sensitive paths", func() {
+		loader := New().WithSensitivePaths("**.password", "app.keys")
+		Expect(loader.Load("app.yaml", []byte(`password: hunter2
+db:
+  password: secret
+keys: [a, b]
+name: api`))).To(BeNil())
+		Expect(isSensitive(loader, "app.password")).To(BeTrue())
+		Expect(isSensitive(loader, "app.db.password")).To(BeTrue())
+		Expect(isSensitive(loader, "app.keys[1]")).To(BeTrue())
+		Expect(isSensitive(loader, "app.name")).To(BeFalse())
+	})
+
+	It("should keep values overriding sensitive values sensitive", func() {
+		loader := New()
+		Expect(loader.Load("app.yaml", []byte(`password: !sensitive changeme
+# gofigure:sensitive
+token: changeme`))).To(BeNil())
+		Expect(loader.Load("app.yaml", []byte(`password: hunter2
+token: abc`))).To(BeNil())
+		Expect(isSensitive(loader, "app.password")).To(BeTrue())
+		Expect(isSensitive(loader, "app.token")).To(BeTrue())
+	})
+
+	It("should mark the values merged under sensitive values", func() {
+		loader := New()
+		Expect(loader.Load("app.yaml", []byte(`db: !sensitive
+  user: admin`))).To(BeNil())
+		Expect(loader.Load("app.yaml", []byte(`db:
+  password: hunter2
+  replicas: [{password: hunter3}]`))).To(BeNil())
+		Expect(isSensitive(loader, "app.db.password")).To(BeTrue())
+		Expect(isSensitive(loader, "app.db.replicas[0].password")).To(BeTrue())
+
+		Expect(loader.Load("storage.yaml", []byte(`db:
+  user: admin`))).To(BeNil())
+		Expect(loader.Load("storage.yaml", []byte(`# gofigure:sensitive
+db:
+  password: hunter2`))).To(BeNil())
+		Expect(isSensitive(loader, "storage.db.user")).To(BeTrue())
+	})
+
+	It("should carry through references", func() {
+		loader := New().WithFeatures(refFeature)
+		Expect(loader.Load("storage.yaml", []byte(`db:
+  user: admin
+  password: !sensitive hunter2`))).To(BeNil())
+		Expect(loader.Load("app.yaml", []byte(`password: !ref storage.db.password
+db: !ref storage.db`))).To(BeNil())
+		Expect(isSensitive(loader, "app.password")).To(BeTrue())
+		Expect(isSensitive(loader, "app.db")).To(BeFalse())
+
+		node, err := loader.GetNode(ctx, "app.db")
+		Expect(err).To(BeNil())
+		Expect(node.ContainsSensitive()).To(BeTrue())
+	})
+
+	It("should redact MarshalYAML", func() {
+		loader := New()
+		Expect(loader.Load("app.yaml", []byte(`password: !sensitive hunter2
+db: !sensitive
+  users: [admin, guest]
+name: api`))).To(BeNil())
+		node, err := loader.GetNode(ctx, "app")
+		Expect(err).To(BeNil())
+
+		b, err := yaml.Marshal(node)
+		Expect(err).To(BeNil())
+		Expect(string(b)).To(Equal(`password: '******'
+db:
+    users: ['******', '******']
+name: api
+`))
+
+		b, err = yaml.Marshal(node.ToYAMLNode())
+		Expect(err).To(BeNil())
+		Expect(string(b)).To(ContainSubstring("hunter2"))
+	})
+
+	It("should redact exports", func() {
+		loader := New()
+		Expect(loader.Load("app.yaml", []byte(`password: !sensitive hunter2
+name: api`))).To(BeNil())
+
+		var w bytes.Buffer
+		Expect(loader.Export(ctx, &w, ExportYAML)).To(BeNil())
+		Expect(w.String()).To(Equal(`app:
+    password: '******'
+    name: api
+`))
+
+		w.Reset()
+		Expect(loader.Export(ctx, &w, ExportDotenv, ExportRevealSensitive())).To(BeNil())
+		Expect(w.String()).To(Equal(`APP__PASSWORD=hunter2
+APP__NAME=api
+`))
+	})
+
+	It("should redact explanations", func() {
+		loader := New()
+		Expect(loader.Load("app.yaml", []byte(`password: !sensitive changeme`))).To(BeNil())
+		Expect(loader.Load("prod/app.yaml", []byte(`password: hunter2`))).To(BeNil())
+		Expect(loader.Load("app.yaml", []byte(`password: hunter3`))).To(BeNil())
+
+		e, err := loader.Explain(ctx, "app.password")
+		Expect(err).To(BeNil())
+		Expect(e.Sensitive).To(BeTrue())
+		Expect(e.String()).To(Equal(`app.password = "******"
+  set by app.yaml:1:11 "******"
+  set by app.yaml:1:11 "******"`))
+		Expect(e.Value.Value()).To(Equal("hunter3"))
+	})
+
+	It("should redact short values from errors", func() {
+		loader := New().WithFeatures(FeatureFunc("!fail", func(ctx context.Context, loader *Loader, node *Node) (*Node, error) {
+			value, err := loader.GetNode(ctx, "app.pin")
+			if err != nil {
+				return nil, err
+			}
+			return nil, NewNodeError(value, errors.New("cannot use "+value.Value()))
+		}))
+		Expect(loader.Load("app.yaml", []byte(`pin: !sensitive 123
+failing: !fail`))).To(BeNil())
+
+		_, err := loader.GetNode(ctx, "app.failing")
+		Expect(err).NotTo(BeNil())
+		Expect(err.Error()).To(Equal("2:10@app.yaml: app.yaml@1:6 cannot use ******"))
+	})
+
+	It("should redact errors", func() {
+		loader := New().WithFeatures(FeatureFunc("!fail", func(ctx context.Context, loader *Loader, node *Node) (*Node, error) {
+			value, err := loader.GetNode(ctx, "app.password")
+			if err != nil {
+				return nil, err
+			}
+			return nil, NewNodeError(value, errors.New("cannot use "+value.Value()))
+		})).WithSensitivePaths("app.port")
+		Expect(loader.Load("app.yaml", []byte(`password: !sensitive hunter2
+port: eighty
+failing: !fail`))).To(BeNil())
+
+		_, err := Get[int](ctx, loader, "app.port")
+		Expect(err).NotTo(BeNil())
+		Expect(err.Error()).To(Equal("app.yaml:2:7: app.port: cannot unmarshal !!str `******` into int"))
+		var decodeError *DecodeError
+		Expect(errors.As(err, &decodeError)).To(BeTrue())
+		Expect(errors.Unwrap(decodeError.Err).Error()).To(ContainSubstring("eighty"))
+
+		_, err = loader.GetNode(ctx, "app.failing")
+		Expect(err).NotTo(BeNil())
+		Expect(err.Error()).To(Equal("3:10@app.yaml: app.yaml@1:11 cannot use ******"))
+	})
+
+	DescribeTable("should only redact whole words", func(message string, values []string, redacted string) {
+		Expect(redactString(message, values)).To(Equal(redacted))
+	},
+		Entry("word", "cannot use hunter2", []string{"hunter2"}, "cannot use ******"),
+		Entry("quoted", "cannot use `hunter2`", []string{"hunter2"}, "cannot use `******`"),
+		Entry("part of a word", "cannot use hunter22 or xhunter2", []string{"hunter2"}, "cannot use hunter22 or xhunter2"),
+		Entry("after a part of a word", "hunter2hunter2 hunter2", []string{"hunter2"}, "hunter2hunter2 ******"),
+		Entry("short", "missing required value", []string{"a", "val"}, "missing required value"),
+		Entry("short word", "cannot use pin 123 or 1234", []string{"123", ""}, "cannot use pin ****** or 1234"),
+		Entry("symbols", "cannot use p@ss:word!", []string{"p@ss:word!"}, "cannot use ******"),
+		Entry("longest first", "cannot use hunter2 hunter", []string{"hunter", "hunter2"}, "cannot use ****** ******"),
+	)
+})
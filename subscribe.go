@@ -0,0 +1,115 @@
+package gofigure
+
+import (
+	"sync"
+)
+
+// ChangeFunc is called with the resolved values at a path before and after it has changed, old is nil if the path did
+// not exist before, and new is nil if it does not exist anymore.
+type ChangeFunc func(old, new *Node)
+
+// Change is a change of the resolved value at Path, as delivered by Subscribe.
+type Change struct {
+	Path string
+	Old  *Node
+	New  *Node
+}
+
+type subscriber struct {
+	path string
+	fn   ChangeFunc
+}
+
+// OnChange registers fn to be called when the resolved value at path changes, after a reload or after anything is
+// loaded. Changes anywhere under path are delivered, as well as changes of the values path depends on through features
+// such as !ref and !tpl. An empty path subscribes to the whole config.
+func (l *Loader) OnChange(path string, fn ChangeFunc) *Loader {
+	l.subscribe(path, fn)
+	return l
+}
+
+// Subscribe returns a channel receiving the changes of the resolved value at path, see OnChange, and a function to
+// unsubscribe and close the channel. Changes are never blocked on the receiver: a change not received yet is merged
+// into the next one, whose Old is the value before both.
+func (l *Loader) Subscribe(path string) (<-chan Change, func()) {
+	var mu sync.Mutex
+	closed := false
+	changes := make(chan Change, 1)
+
+	s := l.subscribe(path, func(old, new *Node) {
+		mu.Lock()
+		defer mu.Unlock()
+		if closed {
+			return
+		}
+
+		change := Change{Path: path, Old: old, New: new}
+		select {
+		case pending := <-changes:
+			change.Old = pending.Old
+		default:
+		}
+		changes <- change
+	})
+
+	return changes, func() {
+		l.unsubscribe(s)
+		mu.Lock()
+		defer mu.Unlock()
+		if !closed {
+			closed = true
+			close(changes)
+		}
+	}
+}
+
+func (l *Loader) subscribe(path string, fn ChangeFunc) *subscriber {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	s := &subscriber{path: path, fn: fn}
+	l.subscribers = append(l.subscribers, s)
+	return s
+}
+
+func (l *Loader) unsubscribe(s *subscriber) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for i := range l.subscribers {
+		if l.subscribers[i] == s {
+			l.subscribers = append(l.subscribers[:i:i], l.subscribers[i+1:]...)
+			return
+		}
+	}
+}
+
+// changes returns the notifications of the subscribers whose values differ between the views before and after a
+// change. Paths that fail to resolve in the new view are skipped, the error is returned by Get instead.
+func (l *Loader) changes(before, after *Loader) []func() {
+	var notifications []func()
+	for _, s := range l.subscribers {
+		var oldValue *Node
+		if before != nil {
+			oldValue, _ = before.lookup(s.path)
+		}
+		newValue, err := after.lookup(s.path)
+		if err != nil {
+			continue
+		}
+
+		var changed []string
+		diffNodes(s.path, oldValue, newValue, &changed)
+		if len(changed) > 0 {
+			fn := s.fn
+			notifications = append(notifications, func() {
+				fn(oldValue, newValue)
+			})
+		}
+	}
+	return notifications
+}
+
+func notify(notifications []func()) {
+	for _, fn := range notifications {
+		fn()
+	}
+}
@@ -0,0 +1,99 @@
+package gofigure
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/psanford/memfs"
+)
+
+var _ = Describe("Subscribe", func() {
+	type change struct {
+		old string
+		new string
+	}
+
+	var loader *Loader
+	var changes map[string][]change
+
+	value := func(n *Node) string {
+		if n == nil {
+			return "<nil>"
+		}
+		return n.Value()
+	}
+
+	record := func(path string) ChangeFunc {
+		return func(old, new *Node) {
+			changes[path] = append(changes[path], change{old: value(old), new: value(new)})
+		}
+	}
+
+	BeforeEach(func() {
+		changes = map[string][]change{}
+		loader = New().WithFeatures(refFeature)
+		Expect(loader.Load("app.yaml", []byte(`port: 8080
+listen: !ref app.port`))).To(BeNil())
+		Expect(loader.Load("storage/db.yaml", []byte(`host: localhost`))).To(BeNil())
+		for _, path := range []string{"", "app", "app.port", "app.listen", "storage", "storage.db", "storage.db.host", "storage.db.port"} {
+			loader.OnChange(path, record(path))
+		}
+	})
+
+	It("should notify subscribers of changed paths and their ancestors", func() {
+		Expect(loader.Load("storage/db.yaml", []byte(`host: remote-address
+port: 3306`))).To(BeNil())
+		Expect(changes).To(HaveLen(5))
+		Expect(changes).To(HaveKey(""))
+		Expect(changes).To(HaveKey("storage"))
+		Expect(changes).To(HaveKey("storage.db"))
+		Expect(changes["storage.db.host"]).To(Equal([]change{{old: "localhost", new: "remote-address"}}))
+		Expect(changes["storage.db.port"]).To(Equal([]change{{old: "<nil>", new: "3306"}}))
+	})
+
+	It("should notify subscribers of dependent values", func() {
+		Expect(loader.Load("app.yaml", []byte(`port: 80`))).To(BeNil())
+		Expect(changes).To(HaveLen(4))
+		Expect(changes).To(HaveKey(""))
+		Expect(changes).To(HaveKey("app"))
+		Expect(changes["app.port"]).To(Equal([]change{{old: "8080", new: "80"}}))
+		Expect(changes["app.listen"]).To(Equal([]change{{old: "8080", new: "80"}}))
+	})
+
+	It("should not notify when values are unchanged", func() {
+		Expect(loader.Load("storage/db.yaml", []byte(`host: localhost`))).To(BeNil())
+		Expect(changes).To(BeEmpty())
+	})
+
+	It("should notify on reload", func() {
+		fs := memfs.New()
+		Expect(fs.WriteFile("cache.yaml", []byte(`host: localhost`), 0644)).To(BeNil())
+		Expect(loader.LoadFS(fs, FSRoot("."))).To(BeNil())
+		loader.OnChange("cache.host", record("cache.host"))
+		changes = map[string][]change{}
+
+		Expect(fs.WriteFile("cache.yaml", []byte(`host: remote-address`), 0644)).To(BeNil())
+		_, err := loader.Reload(context.Background())
+		Expect(err).To(BeNil())
+		Expect(changes["cache.host"]).To(Equal([]change{{old: "localhost", new: "remote-address"}}))
+		Expect(changes).NotTo(HaveKey("app"))
+	})
+
+	It("should deliver changes through channels", func() {
+		ch, cancel := loader.Subscribe("storage.db")
+		Expect(loader.Load("storage/db.yaml", []byte(`host: remote-address`))).To(BeNil())
+		Expect(loader.Load("storage/db.yaml", []byte(`host: another-address`))).To(BeNil())
+
+		var c Change
+		Expect(ch).To(Receive(&c))
+		Expect(c.Path).To(Equal("storage.db"))
+		Expect(value(c.Old.mappingNodes["host"])).To(Equal("localhost"))
+		Expect(value(c.New.mappingNodes["host"])).To(Equal("another-address"))
+		Expect(ch).NotTo(Receive())
+
+		cancel()
+		Expect(loader.Load("storage/db.yaml", []byte(`host: localhost`))).To(BeNil())
+		Expect(ch).To(BeClosed())
+	})
+})
@@ -0,0 +1,479 @@
+package gofigure
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2/unstable"
+	"gopkg.in/yaml.v3"
+)
+
+// decodeTOML parses TOML contents into a node tree. Tables and inline tables become mapping nodes, arrays and arrays
+// of tables become sequence nodes, and every value keeps the line and column it was defined at.
+func decodeTOML(contents []byte, options ...NodeOption) (*Node, error) {
+	root := createNodeWithOptions(options...)
+	root.kind = yaml.MappingNode
+	root.tag = "!!map"
+	root.line = 1
+	root.column = 1
+	root.mappingNodes = map[string]*Node{}
+
+	d := &tomlDecoder{}
+	d.parser.Reset(contents)
+
+	current := root
+	for d.parser.NextExpression() {
+		expr := d.parser.Expression()
+		var err error
+		switch expr.Kind {
+		case unstable.KeyValue:
+			err = d.setKeyValue(current, expr)
+		case unstable.Table:
+			current, err = d.table(root, expr)
+		case unstable.ArrayTable:
+			current, err = d.arrayTable(root, expr)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := d.parser.Error(); err != nil {
+		var parserErr *unstable.ParserError
+		if errors.As(err, &parserErr) {
+			// an empty highlight means the document ended unexpectedly
+			r := unstable.Range{Offset: uint32(len(contents))}
+			if len(parserErr.Highlight) > 0 {
+				r = d.parser.Range(parserErr.Highlight)
+			}
+			shape := d.parser.Shape(r)
+			return nil, fmt.Errorf("%d:%d: %s", shape.Start.Line, shape.Start.Column, parserErr.Message)
+		}
+		return nil, err
+	}
+
+	return root, nil
+}
+
+type tomlDecoder struct {
+	parser unstable.Parser
+}
+
+// position returns the line and column of a TOML node, if the parser kept track of it.
+func (d *tomlDecoder) position(n *unstable.Node) (line, column int, ok bool) {
+	var r unstable.Range
+	switch {
+	case n.Raw.Length > 0:
+		r = n.Raw
+	case n.Kind == unstable.Bool || n.Kind == unstable.LocalDate || n.Kind == unstable.LocalTime ||
+		n.Kind == unstable.LocalDateTime || n.Kind == unstable.DateTime:
+		// these values reference the input directly instead of setting Raw
+		r = d.parser.Range(n.Data)
+	default:
+		return 0, 0, false
+	}
+	shape := d.parser.Shape(r)
+	return shape.Start.Line, shape.Start.Column, true
+}
+
+func (d *tomlDecoder) errorf(n *unstable.Node, format string, args ...any) error {
+	if line, column, ok := d.position(n); ok {
+		return fmt.Errorf("%d:%d: %s", line, column, fmt.Sprintf(format, args...))
+	}
+	return fmt.Errorf(format, args...)
+}
+
+// walk descends into the mapping nodes named by keys, creating missing ones along the way. A sequence of tables on
+// the way is entered through its last element, as TOML does for sub-tables of an array of tables.
+func (d *tomlDecoder) walk(node *Node, keys []*unstable.Node) (*Node, error) {
+	for _, key := range keys {
+		name := string(key.Data)
+		child, ok := node.mappingNodes[name]
+		if !ok {
+			child = d.newMappingNode(node, key)
+			node.setMappingChild(name, child)
+		}
+
+		if child.kind == yaml.SequenceNode && len(child.sequenceNodes) > 0 {
+			child = child.sequenceNodes[len(child.sequenceNodes)-1]
+		}
+		if child.kind != yaml.MappingNode {
+			return nil, d.errorf(key, "key %q is already defined as a value", name)
+		}
+		node = child
+	}
+	return node, nil
+}
+
+func (d *tomlDecoder) newMappingNode(parent *Node, key *unstable.Node) *Node {
+	n := &Node{
+		kind:          yaml.MappingNode,
+		tag:           "!!map",
+		parent:        parent,
+		mappingKey:    string(key.Data),
+		hasMappingKey: true,
+		mappingNodes:  map[string]*Node{},
+	}
+	n.line, n.column, _ = d.position(key)
+	return n
+}
+
+func (d *tomlDecoder) table(root *Node, expr *unstable.Node) (*Node, error) {
+	return d.walk(root, collectTOMLKeys(expr.Key()))
+}
+
+func (d *tomlDecoder) arrayTable(root *Node, expr *unstable.Node) (*Node, error) {
+	keys := collectTOMLKeys(expr.Key())
+	parent, err := d.walk(root, keys[:len(keys)-1])
+	if err != nil {
+		return nil, err
+	}
+
+	key := keys[len(keys)-1]
+	name := string(key.Data)
+	sequence, ok := parent.mappingNodes[name]
+	if !ok {
+		sequence = &Node{
+			kind:          yaml.SequenceNode,
+			tag:           "!!seq",
+			parent:        parent,
+			mappingKey:    name,
+			hasMappingKey: true,
+		}
+		sequence.line, sequence.column, _ = d.position(key)
+		parent.setMappingChild(name, sequence)
+	}
+	if sequence.kind != yaml.SequenceNode {
+		return nil, d.errorf(key, "key %q is already defined as a value", name)
+	}
+
+	table := d.newMappingNode(sequence, key)
+	table.hasMappingKey = false
+	table.mappingKey = ""
+	table.sequenceIndex = len(sequence.sequenceNodes)
+	table.hasSequenceIndex = true
+	sequence.sequenceNodes = append(sequence.sequenceNodes, table)
+	return table, nil
+}
+
+func (d *tomlDecoder) setKeyValue(table *Node, expr *unstable.Node) error {
+	keys := collectTOMLKeys(expr.Key())
+	parent, err := d.walk(table, keys[:len(keys)-1])
+	if err != nil {
+		return err
+	}
+
+	key := keys[len(keys)-1]
+	name := string(key.Data)
+	if _, ok := parent.mappingNodes[name]; ok {
+		return d.errorf(key, "key %q is already defined", name)
+	}
+
+	value := &Node{
+		parent:        parent,
+		mappingKey:    name,
+		hasMappingKey: true,
+	}
+	if err := d.setValue(value, expr.Value(), key); err != nil {
+		return err
+	}
+	parent.setMappingChild(name, value)
+	return nil
+}
+
+//nolint:gocyclo
+func (d *tomlDecoder) setValue(n *Node, value, key *unstable.Node) error {
+	var ok bool
+	n.line, n.column, ok = d.position(value)
+	if !ok {
+		n.line, n.column, _ = d.position(key)
+	}
+
+	switch value.Kind {
+	case unstable.String:
+		n.kind = yaml.ScalarNode
+		n.style = yaml.DoubleQuotedStyle
+		n.tag = "!!str"
+		n.value = string(value.Data)
+	case unstable.Bool:
+		n.kind = yaml.ScalarNode
+		n.tag = "!!bool"
+		n.value = string(value.Data)
+	case unstable.Integer:
+		s := strings.ReplaceAll(string(value.Data), "_", "")
+		i, err := strconv.ParseInt(s, 0, 64)
+		if err != nil {
+			return d.errorf(value, "invalid integer %q", value.Data)
+		}
+		n.kind = yaml.ScalarNode
+		n.tag = "!!int"
+		n.value = strconv.FormatInt(i, 10)
+	case unstable.Float:
+		s := strings.ReplaceAll(string(value.Data), "_", "")
+		switch strings.TrimLeft(s, "+-") {
+		case "inf":
+			s = strings.TrimSuffix(strings.TrimPrefix(s, "+"), "inf") + ".inf"
+		case "nan":
+			s = ".nan"
+		}
+		n.kind = yaml.ScalarNode
+		n.tag = "!!float"
+		n.value = s
+	case unstable.LocalDate, unstable.LocalDateTime, unstable.DateTime:
+		n.kind = yaml.ScalarNode
+		n.tag = "!!timestamp"
+		n.value = string(value.Data)
+	case unstable.LocalTime:
+		n.kind = yaml.ScalarNode
+		n.tag = "!!str"
+		n.value = string(value.Data)
+	case unstable.Array:
+		n.kind = yaml.SequenceNode
+		n.style = yaml.FlowStyle
+		n.tag = "!!seq"
+		n.sequenceNodes = []*Node{}
+		it := value.Children()
+		for it.Next() {
+			child := &Node{
+				parent:           n,
+				sequenceIndex:    len(n.sequenceNodes),
+				hasSequenceIndex: true,
+			}
+			if err := d.setValue(child, it.Node(), key); err != nil {
+				return err
+			}
+			n.sequenceNodes = append(n.sequenceNodes, child)
+		}
+	case unstable.InlineTable:
+		n.kind = yaml.MappingNode
+		n.style = yaml.FlowStyle
+		n.tag = "!!map"
+		n.mappingNodes = map[string]*Node{}
+		it := value.Children()
+		for it.Next() {
+			if err := d.setKeyValue(n, it.Node()); err != nil {
+				return err
+			}
+		}
+	default:
+		return d.errorf(value, "unsupported value %s", value.Kind)
+	}
+	return nil
+}
+
+func collectTOMLKeys(it unstable.Iterator) []*unstable.Node {
+	var keys []*unstable.Node
+	for it.Next() {
+		keys = append(keys, it.Node())
+	}
+	return keys
+}
+
+// encodeTOML writes an exported mapping as TOML, keeping the order of the keys. Mappings become tables and sequences
+// of mappings arrays of tables, except inside arrays where they are written inline. Null values are left out.
+func encodeTOML(w io.Writer, node *Node, provenance bool) error {
+	e := &tomlEncoder{provenance: provenance}
+	if err := e.table(node, nil); err != nil {
+		return err
+	}
+	_, err := w.Write(e.buf.Bytes())
+	return err
+}
+
+type tomlEncoder struct {
+	buf        bytes.Buffer
+	provenance bool
+}
+
+// table writes the key/value pairs of a mapping, followed by its tables and arrays of tables.
+func (e *tomlEncoder) table(node *Node, keys []string) error {
+	for _, key := range node.MappingKeys() {
+		child := node.mappingNodes[key]
+		if isTOMLTable(child) || isTOMLArrayOfTables(child) {
+			continue
+		}
+		v, err := e.value(child)
+		if err != nil {
+			return err
+		}
+		if v == "" {
+			continue
+		}
+		e.buf.WriteString(tomlKey(key) + " = " + v)
+		if e.provenance && child.kind == yaml.ScalarNode {
+			e.buf.WriteString(" # " + provenanceComment(child))
+		}
+		e.buf.WriteByte('\n')
+	}
+
+	for _, key := range node.MappingKeys() {
+		child := node.mappingNodes[key]
+		childKeys := append(keys[:len(keys):len(keys)], key)
+		switch {
+		case isTOMLTable(child):
+			e.header("["+tomlKeys(childKeys)+"]", child)
+			if err := e.table(child, childKeys); err != nil {
+				return err
+			}
+		case isTOMLArrayOfTables(child):
+			for _, element := range child.sequenceNodes {
+				e.header("[["+tomlKeys(childKeys)+"]]", element)
+				if err := e.table(element, childKeys); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (e *tomlEncoder) header(header string, node *Node) {
+	if e.buf.Len() > 0 {
+		e.buf.WriteByte('\n')
+	}
+	e.buf.WriteString(header)
+	if e.provenance {
+		e.buf.WriteString(" # " + provenanceComment(node))
+	}
+	e.buf.WriteByte('\n')
+}
+
+// value returns an inline value, or an empty string for null.
+func (e *tomlEncoder) value(node *Node) (string, error) {
+	switch node.kind {
+	case yaml.MappingNode:
+		var pairs []string
+		for _, key := range node.MappingKeys() {
+			v, err := e.value(node.mappingNodes[key])
+			if err != nil {
+				return "", err
+			}
+			if v != "" {
+				pairs = append(pairs, tomlKey(key)+" = "+v)
+			}
+		}
+		if len(pairs) == 0 {
+			return "{}", nil
+		}
+		return "{ " + strings.Join(pairs, ", ") + " }", nil
+	case yaml.SequenceNode:
+		values := make([]string, 0, len(node.sequenceNodes))
+		for _, child := range node.sequenceNodes {
+			v, err := e.value(child)
+			if err != nil {
+				return "", err
+			}
+			if v == "" {
+				return "", fmt.Errorf("%s: null is not supported in TOML arrays", child.Keypath())
+			}
+			values = append(values, v)
+		}
+		return "[" + strings.Join(values, ", ") + "]", nil
+	}
+
+	v, err := scalarValue(node)
+	if err != nil {
+		return "", err
+	}
+	switch v := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return tomlString(v), nil
+	case float64:
+		return tomlFloat(v), nil
+	case time.Time:
+		return v.Format(time.RFC3339Nano), nil
+	default:
+		return fmt.Sprint(v), nil
+	}
+}
+
+func isTOMLTable(node *Node) bool {
+	return node.kind == yaml.MappingNode
+}
+
+func isTOMLArrayOfTables(node *Node) bool {
+	if node.kind != yaml.SequenceNode || len(node.sequenceNodes) == 0 {
+		return false
+	}
+	for _, child := range node.sequenceNodes {
+		if child.kind != yaml.MappingNode {
+			return false
+		}
+	}
+	return true
+}
+
+func tomlKeys(keys []string) string {
+	quoted := make([]string, len(keys))
+	for i, key := range keys {
+		quoted[i] = tomlKey(key)
+	}
+	return strings.Join(quoted, ".")
+}
+
+func tomlKey(key string) string {
+	if key == "" {
+		return `""`
+	}
+	for _, r := range key {
+		if !(r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '_' || r == '-') {
+			return tomlString(key)
+		}
+	}
+	return key
+}
+
+func tomlString(s string) string {
+	var sb strings.Builder
+	sb.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			sb.WriteString(`\"`)
+		case '\\':
+			sb.WriteString(`\\`)
+		case '\b':
+			sb.WriteString(`\b`)
+		case '\t':
+			sb.WriteString(`\t`)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\f':
+			sb.WriteString(`\f`)
+		case '\r':
+			sb.WriteString(`\r`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&sb, `\u%04X`, r)
+			} else {
+				sb.WriteRune(r)
+			}
+		}
+	}
+	sb.WriteByte('"')
+	return sb.String()
+}
+
+func tomlFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "inf"
+	case math.IsInf(f, -1):
+		return "-inf"
+	case math.IsNaN(f):
+		return "nan"
+	}
+	s := strconv.FormatFloat(f, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".e") {
+		s += ".0"
+	}
+	return s
+}
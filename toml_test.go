@@ -0,0 +1,153 @@
+package gofigure
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v3"
+)
+
+var _ = Describe("TOML", func() {
+	It("should decode toml", func() {
+		node, err := decodeTOML([]byte(`title = "TOML"
+port = 8_080
+mask = 0xff
+ratio = 1.5
+enabled = true
+born = 1979-05-27T07:32:00-08:00
+day = 1979-05-27
+ports = [ 8000, 8001 ]
+owner = { name = "Tom", role.name = "admin" }
+
+[database]
+server = "192.168.1.1"
+
+[database.connection]
+max = 5000
+
+[[servers]]
+name = "alpha"
+
+[[servers]]
+name = "beta"
+
+[servers.tls]
+enabled = false
+`), NodeFilepath("app.toml"))
+		Expect(err).To(BeNil())
+		Expect(node.kind).To(Equal(yaml.MappingNode))
+		Expect(node.Filepath()).To(Equal("app.toml"))
+
+		var s struct {
+			Title   string    `yaml:"title"`
+			Port    int       `yaml:"port"`
+			Mask    int       `yaml:"mask"`
+			Ratio   float64   `yaml:"ratio"`
+			Enabled bool      `yaml:"enabled"`
+			Born    time.Time `yaml:"born"`
+			Day     time.Time `yaml:"day"`
+			Ports   []int     `yaml:"ports"`
+			Owner   struct {
+				Name string `yaml:"name"`
+				Role struct {
+					Name string `yaml:"name"`
+				} `yaml:"role"`
+			} `yaml:"owner"`
+			Database struct {
+				Server     string `yaml:"server"`
+				Connection struct {
+					Max int `yaml:"max"`
+				} `yaml:"connection"`
+			} `yaml:"database"`
+			Servers []struct {
+				Name string `yaml:"name"`
+				TLS  *struct {
+					Enabled bool `yaml:"enabled"`
+				} `yaml:"tls"`
+			} `yaml:"servers"`
+		}
+		Expect(node.ToYAMLNode().Decode(&s)).To(BeNil())
+		Expect(s.Title).To(Equal("TOML"))
+		Expect(s.Port).To(Equal(8080))
+		Expect(s.Mask).To(Equal(255))
+		Expect(s.Ratio).To(Equal(1.5))
+		Expect(s.Enabled).To(BeTrue())
+		Expect(s.Born.UTC()).To(Equal(time.Date(1979, 5, 27, 15, 32, 0, 0, time.UTC)))
+		Expect(s.Day).To(Equal(time.Date(1979, 5, 27, 0, 0, 0, 0, time.UTC)))
+		Expect(s.Ports).To(Equal([]int{8000, 8001}))
+		Expect(s.Owner.Name).To(Equal("Tom"))
+		Expect(s.Owner.Role.Name).To(Equal("admin"))
+		Expect(s.Database.Server).To(Equal("192.168.1.1"))
+		Expect(s.Database.Connection.Max).To(Equal(5000))
+		Expect(s.Servers).To(HaveLen(2))
+		Expect(s.Servers[0].Name).To(Equal("alpha"))
+		Expect(s.Servers[0].TLS).To(BeNil())
+		Expect(s.Servers[1].Name).To(Equal("beta"))
+		Expect(s.Servers[1].TLS).NotTo(BeNil())
+		Expect(s.Servers[1].TLS.Enabled).To(BeFalse())
+	})
+
+	It("should keep line, column and key path", func() {
+		node, err := decodeTOML([]byte(`title = "TOML"
+
+[database]
+  enabled = true
+  ports = [ 8000, 8001 ]
+
+[[servers]]
+name = "alpha"
+`))
+		Expect(err).To(BeNil())
+
+		title, err := node.GetDeep("title")
+		Expect(err).To(BeNil())
+		Expect(title.Line()).To(Equal(1))
+		Expect(title.Column()).To(Equal(9))
+
+		enabled, err := node.GetDeep("database.enabled")
+		Expect(err).To(BeNil())
+		Expect(enabled.Line()).To(Equal(4))
+		Expect(enabled.Column()).To(Equal(13))
+		Expect(enabled.Keypath()).To(Equal("database.enabled"))
+
+		port, err := node.GetDeep("database.ports[1]")
+		Expect(err).To(BeNil())
+		Expect(port.Line()).To(Equal(5))
+		Expect(port.Column()).To(Equal(19))
+		Expect(port.Keypath()).To(Equal("database.ports[1]"))
+
+		name, err := node.GetDeep("servers[0].name")
+		Expect(err).To(BeNil())
+		Expect(name.Line()).To(Equal(8))
+		Expect(name.Keypath()).To(Equal("servers[0].name"))
+	})
+
+	It("should merge with yaml", func() {
+		loader := New()
+		Expect(loader.Load("config/app.yaml", []byte(`env: dev
+database:
+  host: localhost
+  port: 3306`))).To(BeNil())
+		Expect(loader.Load("config/app.toml", []byte(`env = "prod"
+
+[database]
+host = "remote-address"`))).To(BeNil())
+
+		appNode := loader.root.mappingNodes["config"].mappingNodes["app"]
+		Expect(appNode.mappingNodes["env"].value).To(Equal("prod"))
+		Expect(appNode.mappingNodes["database"].mappingNodes["host"].value).To(Equal("remote-address"))
+		Expect(appNode.mappingNodes["database"].mappingNodes["host"].Filepath()).To(Equal("config/app.toml"))
+		Expect(appNode.mappingNodes["database"].mappingNodes["host"].Line()).To(Equal(4))
+		Expect(appNode.mappingNodes["database"].mappingNodes["port"].value).To(Equal("3306"))
+	})
+})
+
+var _ = DescribeTable("TOML failed scenarios", func(source, message string) {
+	_, err := decodeTOML([]byte(source))
+	Expect(err).To(MatchError(message))
+},
+	Entry("duplicated key", "a = 1\na = 2", `2:1: key "a" is already defined`),
+	Entry("table over value", "a = 1\n[a.b]", `2:2: key "a" is already defined as a value`),
+	Entry("invalid syntax", "a = ", "1:5: expected value, not eof"),
+)
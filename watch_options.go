@@ -0,0 +1,39 @@
+package gofigure
+
+import "time"
+
+type watchOptions struct {
+	interval        time.Duration
+	refreshInterval time.Duration
+}
+
+func defaultWatchOptions() *watchOptions {
+	return &watchOptions{
+		interval: time.Second,
+	}
+}
+
+type WatchOption interface {
+	apply(*watchOptions)
+}
+
+type watchOptionFunc func(*watchOptions)
+
+func (f watchOptionFunc) apply(o *watchOptions) {
+	f(o)
+}
+
+// WatchInterval sets how often to poll for changes, every second by default.
+func WatchInterval(interval time.Duration) WatchOption {
+	return watchOptionFunc(func(o *watchOptions) {
+		o.interval = interval
+	})
+}
+
+// WatchRefreshInterval sets how often to reload when nothing loaded has changed, so the values resolved by features
+// that are not cacheable, e.g. environment variables or secrets, are resolved again. They are not by default.
+func WatchRefreshInterval(interval time.Duration) WatchOption {
+	return watchOptionFunc(func(o *watchOptions) {
+		o.refreshInterval = interval
+	})
+}